@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+
+	"github.com/evix1101/ssh-menu/internal/completion"
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/evix1101/ssh-menu/internal/lint"
+	"github.com/evix1101/ssh-menu/internal/state"
+)
+
+// completionCommand prints the completion script for a shell.
+func completionCommand(fs *flag.FlagSet) func([]string) int {
+	return func(args []string) int {
+		if len(args) != 1 {
+			return usageError(fs, "expected one shell")
+		}
+		script, err := completion.Script(args[0])
+		if err != nil {
+			return usageError(fs, "%v", err)
+		}
+		fmt.Print(script)
+		return exitOK
+	}
+}
+
+// completeCommand prints the completion candidates for the words typed
+// after "ssh-menu". It is run by the completion scripts on every Tab
+// press, so it never prints errors: a config that cannot be read just
+// completes nothing.
+func completeCommand(fs *flag.FlagSet) func([]string) int {
+	return func(words []string) int {
+		configPath := sshConfigPath()
+		settings := config.ReadSettings(configPath)
+		hosts := sync.OnceValue(func() []host.Host {
+			st, err := state.Load(state.DefaultPath())
+			if err != nil {
+				st = &state.State{}
+			}
+			var cache *config.Cache
+			if dir := config.DefaultCacheDir(); dir != "" {
+				cache = config.NewCache(dir)
+			}
+			// No state path, so completing never rewrites the state file.
+			l := &hostLoader{configPath: configPath, cache: cache, st: st, stableNumbers: settings.StableNumbers}
+			hosts, _, err := l.load()
+			if err != nil {
+				return nil
+			}
+			return hosts
+		})
+
+		values := map[string]func() []completion.Candidate{
+			"g": func() []completion.Candidate { return completion.Groups(hosts()) },
+			"view": func() []completion.Candidate {
+				var out []completion.Candidate
+				for _, v := range settings.Views {
+					out = append(out, completion.Candidate{Value: v.Name, Desc: v.Query})
+				}
+				return out
+			},
+			"m": func() []completion.Candidate {
+				var out []completion.Candidate
+				for m := host.ModeFuzzy; ; {
+					out = append(out, completion.Candidate{Value: m.String()})
+					if m = m.Next(); m == host.ModeFuzzy {
+						return out
+					}
+				}
+			},
+			"print-format": func() []completion.Candidate { return completion.Words(host.Formats...) },
+			"fail-on": func() []completion.Candidate {
+				return completion.Words(lint.Info.String(), lint.Warning.String(), lint.Error.String())
+			},
+			"list.format":   func() []completion.Candidate { return completion.Words(append([]string{"table"}, host.Formats...)...) },
+			"export.format": func() []completion.Candidate { return completion.Words("json", "csv") },
+		}
+		args := map[argKind]func() []completion.Candidate{
+			argHost:        func() []completion.Candidate { return completion.Hosts(hosts()) },
+			argShell:       func() []completion.Candidate { return completion.Words(completion.Shells...) },
+			argGroupAction: func() []completion.Candidate { return completion.Words("add", "remove") },
+		}
+
+		// commandSpec describes a command's flags and first argument.
+		commandSpec := func(c command) completion.Spec {
+			cfs := c.flagSet()
+			c.setup(cfs)
+			spec := completion.Spec{Args: args[c.arg]}
+			cfs.VisitAll(func(f *flag.Flag) {
+				b, ok := f.Value.(interface{ IsBoolFlag() bool })
+				v := values[c.name+"."+f.Name]
+				if v == nil {
+					v = values[f.Name]
+				}
+				spec.Flags = append(spec.Flags, completion.Flag{
+					Name:       f.Name,
+					Usage:      f.Usage,
+					TakesValue: !ok || !b.IsBoolFlag(),
+					Values:     v,
+				})
+			})
+			return spec
+		}
+
+		spec := commandSpec(rootCmd)
+		for _, c := range commands() {
+			if c.hidden {
+				continue
+			}
+			spec.Commands = append(spec.Commands, completion.Command{Name: c.name, Desc: c.summary, Spec: commandSpec(c)})
+			for _, a := range c.aliases {
+				spec.Commands = append(spec.Commands, completion.Command{Name: a, Desc: c.summary, Spec: commandSpec(c)})
+			}
+		}
+
+		for _, c := range completion.Complete(spec, words) {
+			fmt.Println(c)
+		}
+		return exitOK
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/evix1101/ssh-menu/internal/theme"
+	"github.com/evix1101/ssh-menu/internal/ui"
+	"github.com/evix1101/ssh-menu/internal/watch"
+)
+
+// connectOptions are the flags of connect, which are also the flags of a
+// bare "ssh-menu".
+type connectOptions struct {
+	load        *loadFlags
+	sel         *selectFlags
+	verbose     *bool
+	sshOpts     *string
+	print       *bool
+	printFormat *string
+	inline      *bool
+	height      *string
+}
+
+func addConnectFlags(fs *flag.FlagSet) *connectOptions {
+	return &connectOptions{
+		load:        addLoadFlags(fs),
+		sel:         addSelectFlags(fs),
+		verbose:     fs.Bool("V", false, "Enable SSH verbose mode (-v flag)"),
+		sshOpts:     fs.String("s", "", "Additional SSH options to pass through"),
+		print:       fs.Bool("print", false, "Print the selected host to stdout instead of connecting"),
+		printFormat: fs.String("print-format", host.FormatAlias, "What --print outputs: "+strings.Join(host.Formats, ", ")),
+		inline:      fs.Bool("inline", false, "Draw the menu below the prompt instead of full screen"),
+		height:      fs.String("height", "", "Height of the inline menu in lines or percent (default 40%); implies --inline"),
+	}
+}
+
+func connectCommand(fs *flag.FlagSet) func([]string) int {
+	o := addConnectFlags(fs)
+	return func(args []string) int {
+		return o.run(fs, args)
+	}
+}
+
+// run connects to the host named in args, or to the one picked in the menu.
+func (o *connectOptions) run(fs *flag.FlagSet, args []string) int {
+	if len(args) > 1 {
+		return usageError(fs, "too many arguments; use 'ssh-menu exec' to run a command on a host")
+	}
+	var height ui.Height
+	if *o.inline || *o.height != "" {
+		if *o.height == "" {
+			*o.height = "40%"
+		}
+		var err error
+		if height, err = ui.ParseHeight(*o.height); err != nil {
+			return usageError(fs, "%v", err)
+		}
+	}
+	if *o.print && !slices.Contains(host.Formats, *o.printFormat) {
+		return usageError(fs, "unknown print format '%s' (use %s)", *o.printFormat, strings.Join(host.Formats, ", "))
+	}
+
+	e := o.load.newEnv()
+	theme.Init(e.configPath)
+	matchMode, err := o.sel.matchMode(e.settings)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+	view, err := o.sel.findView(e.settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	hosts, files, ok := e.hosts()
+	if !ok {
+		return exitError
+	}
+	if hosts, err = o.sel.narrow(hosts, matchMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if len(args) == 1 {
+		if view.Query != "" {
+			hosts = host.FilterHosts(view.Query, hosts)
+		}
+		h := findHost(args[0], hosts)
+		if h == nil {
+			fmt.Fprintf(os.Stderr, "Host not found: %s\n", args[0])
+			return exitError
+		}
+		return o.finish(e, *h)
+	}
+
+	opts := ui.Options{
+		Verbose:     *o.verbose,
+		SSHOpts:     *o.sshOpts,
+		Views:       e.settings.Views,
+		InitialView: view.Name,
+		Keys:        e.settings.Keys,
+		Columns:     e.settings.Columns,
+		MatchMode:   matchMode,
+		Height:      height,
+		Pin: func(h host.Host, pin bool) error {
+			c, err := e.pinChange(h, pin)
+			if err != nil {
+				return err
+			}
+			return c.Write()
+		},
+		SaveOrder: func(hosts, groups []string) error {
+			e.st.SetArrangement(hosts, groups)
+			return e.st.Save(e.statePath)
+		},
+	}
+	opts.Order, opts.GroupOrder = e.st.Arrangement()
+	if *o.print || height != (ui.Height{}) {
+		// stdout may be captured, as in ssh $(ssh-menu --print), so the
+		// menu is drawn on the terminal directly.
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: no terminal to draw the menu on: %v\n", err)
+			return exitError
+		}
+		defer tty.Close()
+		opts.TTY = tty
+	}
+	w, err := watch.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not watching SSH config for changes: %v\n", err)
+	} else {
+		configDir := config.ConfigDir(e.configPath)
+		w.Set(files, []string{configDir})
+		opts.Changes = w.Changes()
+		opts.Reload = func() ([]host.Host, error) {
+			hosts, files, err := e.loader.load()
+			if err != nil {
+				return nil, err
+			}
+			w.Set(files, []string{configDir})
+			return o.sel.narrow(hosts, matchMode)
+		}
+	}
+
+	m := ui.New(hosts, opts)
+	err = ui.Run(m)
+	if w != nil {
+		w.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
+		return exitError
+	}
+
+	if m.Selected == nil {
+		if *o.print {
+			// Nothing was chosen, so callers such as shell widgets must
+			// not use the empty output.
+			return exitError
+		}
+		return exitOK
+	}
+	return o.finish(e, *m.Selected)
+}
+
+// finish connects to the chosen host, or prints it with --print.
+func (o *connectOptions) finish(e *env, h host.Host) int {
+	if *o.print {
+		text, err := host.Format(h, *o.printFormat, *o.verbose, *o.sshOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		fmt.Println(text)
+		return exitOK
+	}
+	recordConnection(e.st, e.statePath, h)
+	return runAttached(sshCommand(h, *o.verbose, *o.sshOpts))
+}
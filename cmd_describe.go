@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// describeCommand rewrites the description in a host's # Menu comment.
+func describeCommand(fs *flag.FlagSet) func([]string) int {
+	ef := addEditFlags(fs)
+	return func(args []string) int {
+		if len(args) < 2 {
+			return usageError(fs, "expected a host and a description")
+		}
+		desc := strings.TrimSpace(strings.Join(args[1:], " "))
+		if desc == "" {
+			return usageError(fs, "description must not be empty")
+		}
+		return ef.edit(args[0], func(_ *env, h *host.Host) (change, error) {
+			return config.SetDescription(h.SourceFile, h.ShortName, desc)
+		}, "is now described as "+desc, "is already described as "+desc)
+	}
+}
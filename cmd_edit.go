@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/evix1101/ssh-menu/internal/editor"
+)
+
+func editCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	return func(args []string) int {
+		if len(args) != 1 {
+			return usageError(fs, "expected one host")
+		}
+		h, ok := load.newEnv().host(args[0])
+		if !ok {
+			return exitError
+		}
+		return runAttached(editor.Command(h.SourceFile, h.Line))
+	}
+}
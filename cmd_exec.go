@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func execCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	sel := addSelectFlags(fs)
+	verbose := fs.Bool("V", false, "Enable SSH verbose mode (-v flag)")
+	sshOpts := fs.String("s", "", "Additional SSH options to pass through")
+	return func(args []string) int {
+		e := load.newEnv()
+		hosts, _, ok := e.hosts()
+		if !ok {
+			return exitError
+		}
+
+		// With -g, -q or --view every argument is the command; otherwise
+		// the first one names the host.
+		if sel.set() {
+			var err error
+			if hosts, err = sel.selectHosts(e.settings, hosts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return exitError
+			}
+		} else {
+			if len(args) == 0 {
+				return usageError(fs, "expected a host, or -g, -q or --view")
+			}
+			h := findHost(args[0], hosts)
+			if h == nil {
+				fmt.Fprintf(os.Stderr, "Host not found: %s\n", args[0])
+				return exitError
+			}
+			hosts, args = hosts[:0:0], args[1:]
+			hosts = append(hosts, *h)
+		}
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return usageError(fs, "expected a command to run")
+		}
+
+		if len(hosts) == 1 {
+			return runAttached(sshCommand(hosts[0], *verbose, *sshOpts, args...))
+		}
+		var failed []string
+		for i, h := range hosts {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", h.ShortName)
+			if runAttached(sshCommand(h, *verbose, *sshOpts, args...)) != exitOK {
+				failed = append(failed, h.ShortName)
+			}
+		}
+		if len(failed) > 0 {
+			fmt.Fprintf(os.Stderr, "Failed on %d of %d hosts: %s\n", len(failed), len(hosts), strings.Join(failed, ", "))
+			return exitError
+		}
+		return exitOK
+	}
+}
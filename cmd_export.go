@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// exportedHost is the exported form of a host. Its JSON field names are
+// part of the export format, so they must not change.
+type exportedHost struct {
+	Menu         int               `json:"menu"`
+	Alias        string            `json:"alias"`
+	HostName     string            `json:"hostname,omitempty"`
+	User         string            `json:"user,omitempty"`
+	Port         string            `json:"port,omitempty"`
+	IP           string            `json:"ip,omitempty"`
+	IdentityFile string            `json:"identity_file,omitempty"`
+	Description  string            `json:"description"`
+	Groups       []string          `json:"groups"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Pinned       bool              `json:"pinned"`
+	File         string            `json:"file"`
+	Line         int               `json:"line"`
+}
+
+func exportHost(h host.Host) exportedHost {
+	groups := h.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	return exportedHost{
+		Menu:         h.MenuNumber,
+		Alias:        h.ShortName,
+		HostName:     h.LongName,
+		User:         h.User,
+		Port:         h.Port,
+		IP:           h.IP,
+		IdentityFile: h.IdentityFile,
+		Description:  h.DescText,
+		Groups:       groups,
+		Tags:         h.Tags,
+		Pinned:       h.Pinned,
+		File:         h.SourceFile,
+		Line:         h.Line,
+	}
+}
+
+func exportCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	sel := addSelectFlags(fs)
+	format := fs.String("format", "json", "Output format: json or csv")
+	return func(args []string) int {
+		if len(args) > 0 {
+			return usageError(fs, "export takes no arguments")
+		}
+		if *format != "json" && *format != "csv" {
+			return usageError(fs, "unknown format '%s' (use json or csv)", *format)
+		}
+		e := load.newEnv()
+		hosts, _, ok := e.hosts()
+		if !ok {
+			return exitError
+		}
+		hosts, err := sel.selectHosts(e.settings, hosts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+
+		if *format == "csv" {
+			err = writeCSV(hosts)
+		} else {
+			out := make([]exportedHost, len(hosts))
+			for i, h := range hosts {
+				out[i] = exportHost(h)
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(out)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+}
+
+func writeCSV(hosts []host.Host) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"menu", "alias", "hostname", "user", "port", "ip", "identity_file", "description", "groups", "tags", "pinned", "file", "line"})
+	for _, h := range hosts {
+		x := exportHost(h)
+		w.Write([]string{
+			strconv.Itoa(x.Menu), x.Alias, x.HostName, x.User, x.Port, x.IP, x.IdentityFile, x.Description,
+			strings.Join(x.Groups, ";"), h.TagString(), strconv.FormatBool(x.Pinned), x.File, strconv.Itoa(x.Line),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// groupCommand adds a # Group comment to a host or removes one.
+func groupCommand(fs *flag.FlagSet) func([]string) int {
+	ef := addEditFlags(fs)
+	return func(args []string) int {
+		if len(args) != 3 {
+			return usageError(fs, "expected add or remove, a host and a group")
+		}
+		action, name, group := args[0], args[1], args[2]
+		if group == "" {
+			return usageError(fs, "group must not be empty")
+		}
+		switch action {
+		case "add":
+			return ef.edit(name, func(_ *env, h *host.Host) (change, error) {
+				return config.AddGroup(h.SourceFile, h.ShortName, group)
+			}, "is now in group "+group, "is already in group "+group)
+		case "remove":
+			return ef.edit(name, func(_ *env, h *host.Host) (change, error) {
+				return config.RemoveGroup(h.SourceFile, h.ShortName, group)
+			}, "is no longer in group "+group, "is not in group "+group)
+		}
+		return usageError(fs, "unknown action '%s' (use add or remove)", action)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func groupsCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	return func(args []string) int {
+		if len(args) > 0 {
+			return usageError(fs, "groups takes no arguments")
+		}
+		return runGroups(load)
+	}
+}
+
+// runGroups prints the groups and saved views.
+func runGroups(load *loadFlags) int {
+	e := load.newEnv()
+	hosts, _, ok := e.hosts()
+	if !ok {
+		return exitError
+	}
+	_, order := e.st.Arrangement()
+	listGroups(hosts, order)
+	listViews(e.settings.Views)
+	return exitOK
+}
+
+// listGroups prints the groups in the order of the menu's group tabs.
+func listGroups(hosts []host.Host, order []string) {
+	groups := host.OrderGroups(host.GetAllGroups(hosts), order)
+	if len(groups) == 0 {
+		fmt.Println("No groups found in SSH config.")
+		return
+	}
+	fmt.Println("Available groups:")
+	for _, g := range groups {
+		count := len(host.HostsForGroup(hosts, g))
+		fmt.Printf("  %s (%d hosts)\n", g, count)
+	}
+}
+
+func listViews(views []config.View) {
+	if len(views) == 0 {
+		return
+	}
+	fmt.Println("Saved views:")
+	for _, v := range views {
+		fmt.Printf("  %s = %s\n", v.Name, v.Query)
+	}
+}
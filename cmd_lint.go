@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/lint"
+)
+
+const lintResolveTimeout = 2 * time.Second
+
+// lintCommand checks the SSH config files and prints the findings. It
+// exits with exitError if any finding reaches the --fail-on severity.
+func lintCommand(fs *flag.FlagSet) func([]string) int {
+	jsonOut := fs.Bool("json", false, "Print findings as JSON")
+	dns := fs.Bool("dns", false, "Also run checks that resolve host names")
+	failOn := fs.String("fail-on", "error", "Exit non-zero on findings of this severity or worse: info, warning or error")
+	return func(args []string) int {
+		if len(args) > 0 {
+			return usageError(fs, "lint takes no arguments")
+		}
+		threshold, err := lint.ParseSeverity(*failOn)
+		if err != nil {
+			return usageError(fs, "%v", err)
+		}
+		return runLint(sshConfigPath(), *jsonOut, *dns, threshold)
+	}
+}
+
+// runLint lints the config files. Checks that resolve host names only run
+// with dns set, as each lookup can take up to lintResolveTimeout.
+func runLint(configPath string, jsonOut, dns bool, threshold lint.Severity) int {
+	_, files, err := config.ReadConfigFilesCached(configPath, nil)
+	if err != nil {
+		// Lint still reports why the main config cannot be read.
+		files = []string{configPath}
+	}
+
+	var opts lint.Options
+	if dns {
+		opts.Resolve = func(name string) ([]string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), lintResolveTimeout)
+			defer cancel()
+			return net.DefaultResolver.LookupHost(ctx, name)
+		}
+	}
+	findings := lint.Run(files, opts)
+
+	if jsonOut {
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+		fmt.Printf("%d files checked: %d errors, %d warnings, %d notes\n", len(files),
+			lint.Count(findings, lint.Error),
+			lint.Count(findings, lint.Warning)-lint.Count(findings, lint.Error),
+			len(findings)-lint.Count(findings, lint.Warning))
+	}
+
+	if lint.Count(findings, threshold) > 0 {
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestLint_SkipsDNSByDefault(t *testing.T) {
+	testEnv(t, `# Menu 1: Web server
+# IP: 10.9.9.9
+Host web
+    HostName web.invalid
+    ProxyJump gw.invalid
+`)
+	// Both checks would need a lookup, which only --dns runs.
+	code, stdout, stderr := runCaptured(t, "lint", "--fail-on", "info")
+	if code != exitOK {
+		t.Errorf("expected no findings without --dns, got exit code %d: %s%s", code, stdout, stderr)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func listCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	sel := addSelectFlags(fs)
+	format := fs.String("format", "table", "Output format: table, or one value per host as "+strings.Join(host.Formats, ", "))
+	return func(args []string) int {
+		if len(args) > 0 {
+			return usageError(fs, "list takes no arguments")
+		}
+		if *format != "table" && !slices.Contains(host.Formats, *format) {
+			return usageError(fs, "unknown format '%s'", *format)
+		}
+		e := load.newEnv()
+		hosts, _, ok := e.hosts()
+		if !ok {
+			return exitError
+		}
+		hosts, err := sel.selectHosts(e.settings, hosts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		// List hosts in menu order: pinned first, then as arranged.
+		hosts = host.SortWithPins(hosts)
+
+		if *format != "table" {
+			for _, h := range hosts {
+				// Hosts without a value, such as those without an IP,
+				// are left out.
+				if text, err := host.Format(h, *format, false, ""); err == nil {
+					fmt.Println(text)
+				}
+			}
+			return exitOK
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "#\tALIAS\tHOSTNAME\tGROUPS\tDESCRIPTION")
+		for _, h := range hosts {
+			mark := ""
+			if h.Pinned {
+				mark = " ★"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", h.MenuNumber, h.ShortName+mark, h.HostName(),
+				strings.Join(h.Groups, ","), h.DescText)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/evix1101/ssh-menu/internal/state"
+)
+
+// pinCommand returns the setup of pin, or of unpin when pin is false.
+func pinCommand(pin bool) func(fs *flag.FlagSet) func([]string) int {
+	return func(fs *flag.FlagSet) func([]string) int {
+		ef := addEditFlags(fs)
+		return func(args []string) int {
+			if len(args) != 1 {
+				return usageError(fs, "expected one host")
+			}
+			verb := "pinned"
+			if !pin {
+				verb = "unpinned"
+			}
+			return ef.edit(args[0], func(e *env, h *host.Host) (change, error) {
+				return e.pinChange(*h, pin)
+			}, "is now "+verb, "is already "+verb)
+		}
+	}
+}
+
+// pinChange pins or unpins h in the pin store chosen with # PinStore: its
+// config file by default, or the state file.
+func (e *env) pinChange(h host.Host, pin bool) (change, error) {
+	if e.settings.PinStore != config.PinStoreState {
+		if h.Pinned == pin {
+			return config.Change{Path: h.SourceFile}, nil
+		}
+		if h.Pinned != h.PinnedInConfig() {
+			return nil, fmt.Errorf("%s is %s in the state file; set '# PinStore: state' to change it", h.ShortName, pinnedWord(h.Pinned))
+		}
+		return config.SetPinned(h.SourceFile, h.ShortName, pin)
+	}
+	old, err := os.ReadFile(e.statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	c := &statePinChange{st: e.st, path: e.statePath, alias: h.ShortName, pin: pin, inConfig: h.PinnedInConfig(), old: old}
+	// Pin in a copy, leaving the loaded state alone until the change is
+	// written.
+	c.new, err = e.st.Preview(func(next *state.State) {
+		c.changed = next.SetPinned(c.alias, c.pin, c.inConfig)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func pinnedWord(pinned bool) string {
+	if pinned {
+		return "pinned"
+	}
+	return "unpinned"
+}
+
+// statePinChange is a pin saved in the state file.
+type statePinChange struct {
+	st            *state.State
+	path          string
+	alias         string
+	pin, inConfig bool
+	changed       bool
+	old, new      []byte
+}
+
+func (c *statePinChange) Changed() bool { return c.changed }
+func (c *statePinChange) Diff() string  { return config.UnifiedDiff(c.path, c.old, c.new) }
+func (c *statePinChange) File() string  { return c.path }
+
+func (c *statePinChange) Write() error {
+	c.st.SetPinned(c.alias, c.pin, c.inConfig)
+	return c.st.Save(c.path)
+}
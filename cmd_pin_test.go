@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testEnv writes config as the SSH config of a temporary home directory
+// and returns an env reading it.
+func testEnv(t *testing.T, config string) *env {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	load := addLoadFlags(flag.NewFlagSet("test", flag.ContinueOnError))
+	return load.newEnv()
+}
+
+// TestPinDuringReload pins hosts while the config is reloaded, as the menu
+// does when the config changes while a pin is saved. Run with -race.
+func TestPinDuringReload(t *testing.T) {
+	e := testEnv(t, `# PinStore: state
+# StableNumbers: true
+
+# Menu: Web
+Host web
+
+# Menu: Db
+Host db
+`)
+	hosts, _, ok := e.hosts()
+	if !ok {
+		t.Fatal("expected hosts")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			if _, _, err := e.loader.load(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := range 50 {
+		h := hosts[i%len(hosts)]
+		c, err := e.pinChange(h, i%4 < 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Write(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	hosts, _, _ = e.hosts()
+	for _, h := range hosts {
+		if !h.Pinned {
+			t.Errorf("expected %s to be pinned by the last changes", h.ShortName)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func renumberCommand(fs *flag.FlagSet) func([]string) int {
+	load := addLoadFlags(fs)
+	return func(args []string) int {
+		if len(args) > 0 {
+			return usageError(fs, "renumber takes no arguments")
+		}
+		hosts, _, ok := load.newEnv().hosts()
+		if !ok {
+			return exitError
+		}
+		if err := renumber(hosts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+}
+
+// renumber writes explicit # Menu numbers into the config files for every
+// host whose number is currently auto-assigned.
+func renumber(hosts []host.Host) error {
+	byFile := make(map[string]map[string]int)
+	for _, h := range hosts {
+		if !h.AutoNumber {
+			continue
+		}
+		if byFile[h.SourceFile] == nil {
+			byFile[h.SourceFile] = make(map[string]int)
+		}
+		byFile[h.SourceFile][h.ShortName] = h.MenuNumber
+	}
+	if len(byFile) == 0 {
+		fmt.Println("All hosts already have explicit menu numbers.")
+		return nil
+	}
+
+	files := make([]string, 0, len(byFile))
+	for f := range byFile {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := config.WriteMenuNumbers(f, byFile[f]); err != nil {
+			return err
+		}
+		fmt.Printf("Numbered %d hosts in %s\n", len(byFile[f]), f)
+	}
+	return nil
+}
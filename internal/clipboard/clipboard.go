@@ -0,0 +1,84 @@
+// Package clipboard copies text to the system clipboard.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Copy puts text on the clipboard. It writes an OSC 52 escape sequence to
+// the terminal w, which works over SSH and inside tmux when the terminal
+// supports it, and also runs a local clipboard tool (pbcopy, clip,
+// wl-copy, xclip or xsel) when one is available. It fails only if neither
+// worked.
+func Copy(w io.Writer, text string) error {
+	seq := sequence(text, os.Getenv("TMUX") != "", strings.HasPrefix(os.Getenv("TERM"), "screen"))
+	_, oscErr := io.WriteString(w, seq)
+
+	tool := localTool()
+	if tool == nil {
+		return oscErr
+	}
+	cmd := exec.Command(tool[0], tool[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	toolErr := cmd.Run()
+	if oscErr != nil && toolErr != nil {
+		return errors.Join(oscErr, fmt.Errorf("%s: %w", tool[0], toolErr))
+	}
+	return nil
+}
+
+// sequence returns the OSC 52 sequence setting the clipboard to text.
+// Inside tmux and GNU screen the sequence is wrapped in a DCS passthrough
+// so it reaches the outer terminal.
+func sequence(text string, tmux, screen bool) string {
+	osc := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch {
+	case tmux:
+		return "\x1bPtmux;" + strings.ReplaceAll(osc, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case screen:
+		// screen limits the length of a DCS string, so long sequences are
+		// sent as several consecutive ones.
+		const chunk = 76
+		var b strings.Builder
+		for len(osc) > 0 {
+			n := min(chunk, len(osc))
+			b.WriteString("\x1bP" + osc[:n] + "\x1b\\")
+			osc = osc[n:]
+		}
+		return b.String()
+	}
+	return osc
+}
+
+// localTool returns the command line of a clipboard tool for the local
+// display, or nil when there is none. Over SSH the local tools would set
+// the remote machine's clipboard, so only OSC 52 is used.
+func localTool() []string {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return nil
+	}
+	var candidates [][]string
+	switch {
+	case runtime.GOOS == "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case runtime.GOOS == "windows":
+		candidates = [][]string{{"clip"}}
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		candidates = [][]string{{"wl-copy"}}
+	case os.Getenv("DISPLAY") != "":
+		candidates = [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package clipboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	if got := sequence("hi", false, false); got != "\x1b]52;c;aGk=\a" {
+		t.Errorf("unexpected OSC 52 sequence %q", got)
+	}
+}
+
+func TestSequence_Tmux(t *testing.T) {
+	want := "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"
+	if got := sequence("hi", true, false); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSequence_ScreenChunks(t *testing.T) {
+	got := sequence(strings.Repeat("x", 200), false, true)
+	parts := strings.Split(got, "\x1b\\")
+	if len(parts) < 3 || parts[len(parts)-1] != "" {
+		t.Fatalf("expected several DCS strings, got %q", got)
+	}
+	var osc strings.Builder
+	for _, p := range parts[:len(parts)-1] {
+		if !strings.HasPrefix(p, "\x1bP") || len(p) > 78 {
+			t.Fatalf("bad chunk %q", p)
+		}
+		osc.WriteString(strings.TrimPrefix(p, "\x1bP"))
+	}
+	if osc.String() != sequence(strings.Repeat("x", 200), false, false) {
+		t.Error("chunks do not reassemble to the OSC 52 sequence")
+	}
+}
+
+func TestCopy_WritesSequence(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+	t.Setenv("SSH_TTY", "/dev/pts/0") // keep local tools out of the test
+	var buf bytes.Buffer
+	if err := Copy(&buf, "ssh web"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != sequence("ssh web", false, false) {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
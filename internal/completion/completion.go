@@ -0,0 +1,207 @@
+// Package completion generates shell completion scripts for ssh-menu and
+// computes the candidates they offer.
+//
+// The scripts are thin: they pass the words typed so far to the hidden
+// "ssh-menu __complete" command, which prints one candidate per line as
+// value, tab, description.
+package completion
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+//go:embed scripts
+var scripts embed.FS
+
+// Shells lists the shells completion scripts are available for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Script returns the completion script for shell.
+func Script(shell string) (string, error) {
+	data, err := scripts.ReadFile("scripts/ssh-menu." + shell)
+	if err != nil {
+		return "", fmt.Errorf("no completion for shell %q (use %s)", shell, strings.Join(Shells, ", "))
+	}
+	return string(data), nil
+}
+
+// Candidate is one completion offered to the shell.
+type Candidate struct {
+	Value string
+	Desc  string
+}
+
+// String returns the candidate as printed by __complete.
+func (c Candidate) String() string {
+	if c.Desc == "" {
+		return c.Value
+	}
+	return c.Value + "\t" + c.Desc
+}
+
+// Flag is a command-line flag that can be completed.
+type Flag struct {
+	Name  string
+	Usage string
+	// TakesValue is false for boolean flags.
+	TakesValue bool
+	// Values, if set, returns the candidates for the flag's value.
+	Values func() []Candidate
+}
+
+// Command is a subcommand that can be completed.
+type Command struct {
+	Name string
+	Desc string
+	// Spec describes the flags and arguments after the command name.
+	Spec Spec
+}
+
+// Spec describes the command line being completed.
+type Spec struct {
+	Flags    []Flag
+	Commands []Command
+	// Args, if set, returns the candidates for the first positional
+	// argument, offered alongside the commands.
+	Args func() []Candidate
+}
+
+// Complete returns the candidates for the last of words, which are the
+// words typed after the program name. The last word is the one being
+// completed and may be empty.
+func Complete(spec Spec, words []string) []Candidate {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+
+	var positional []string
+	for i := 0; i < len(words)-1; i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			if len(positional) == 0 {
+				if c := spec.command(w); c != nil {
+					return Complete(c.Spec, words[i+1:])
+				}
+			}
+			positional = append(positional, w)
+			continue
+		}
+		if f := spec.flag(w); f != nil && f.TakesValue && !strings.Contains(w, "=") {
+			if i == len(words)-2 {
+				// Completing the flag's value.
+				if f.Values == nil {
+					return nil
+				}
+				return filter(f.Values(), cur)
+			}
+			i++
+		}
+	}
+
+	if strings.HasPrefix(cur, "-") && len(positional) == 0 {
+		return filter(spec.flagCandidates(), cur)
+	}
+	if len(positional) > 0 {
+		return nil
+	}
+	var all []Candidate
+	for _, c := range spec.Commands {
+		all = append(all, Candidate{c.Name, c.Desc})
+	}
+	if spec.Args != nil {
+		all = append(all, spec.Args()...)
+	}
+	return filter(all, cur)
+}
+
+func (s Spec) command(name string) *Command {
+	for i := range s.Commands {
+		if s.Commands[i].Name == name {
+			return &s.Commands[i]
+		}
+	}
+	return nil
+}
+
+// flag finds the flag named by w, which may be written with one or two
+// dashes and may carry an "=value".
+func (s Spec) flag(w string) *Flag {
+	name := strings.TrimLeft(w, "-")
+	name, _, _ = strings.Cut(name, "=")
+	for i := range s.Flags {
+		if s.Flags[i].Name == name {
+			return &s.Flags[i]
+		}
+	}
+	return nil
+}
+
+// flagCandidates lists the flags, with one dash for single-letter flags
+// and two for longer ones.
+func (s Spec) flagCandidates() []Candidate {
+	var all []Candidate
+	for _, f := range s.Flags {
+		prefix := "--"
+		if len(f.Name) == 1 {
+			prefix = "-"
+		}
+		all = append(all, Candidate{prefix + f.Name, f.Usage})
+	}
+	return all
+}
+
+func filter(candidates []Candidate, prefix string) []Candidate {
+	var out []Candidate
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Hosts returns the menu numbers and aliases of hosts, described by their
+// menu descriptions.
+func Hosts(hosts []host.Host) []Candidate {
+	sorted := append([]host.Host(nil), hosts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].MenuNumber < sorted[j].MenuNumber })
+
+	var out []Candidate
+	for _, h := range sorted {
+		out = append(out, Candidate{strconv.Itoa(h.MenuNumber), h.ShortName + ": " + h.DescText})
+	}
+	seen := make(map[string]bool)
+	for _, h := range sorted {
+		if seen[h.ShortName] {
+			continue
+		}
+		seen[h.ShortName] = true
+		out = append(out, Candidate{h.ShortName, h.DescText})
+	}
+	return out
+}
+
+// Groups returns the groups of hosts with their host counts.
+func Groups(hosts []host.Host) []Candidate {
+	var out []Candidate
+	for _, g := range host.GetAllGroups(hosts) {
+		out = append(out, Candidate{g, fmt.Sprintf("%d hosts", len(host.HostsForGroup(hosts, g)))})
+	}
+	return out
+}
+
+// Words returns candidates without descriptions.
+func Words(words ...string) []Candidate {
+	out := make([]Candidate, len(words))
+	for i, w := range words {
+		out[i] = Candidate{Value: w}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package completion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Flags: []Flag{
+			{Name: "g", Usage: "Filter by group", TakesValue: true, Values: func() []Candidate { return Words("Prod", "Staging") }},
+			{Name: "s", Usage: "SSH options", TakesValue: true},
+			{Name: "view", Usage: "Open a view", TakesValue: true, Values: func() []Candidate { return Words("DBs") }},
+			{Name: "V", Usage: "Verbose"},
+		},
+		Commands: []Command{
+			{Name: "completion", Spec: Spec{Args: func() []Candidate { return Words(Shells...) }}},
+			{Name: "lint", Spec: Spec{Flags: []Flag{{Name: "json"}, {Name: "fail-on", TakesValue: true, Values: func() []Candidate { return Words("error") }}}}},
+		},
+		Args: func() []Candidate { return Words("1", "web", "db") },
+	}
+}
+
+func values(candidates []Candidate) []string {
+	var out []string
+	for _, c := range candidates {
+		out = append(out, c.Value)
+	}
+	return out
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"completion", "lint", "1", "web", "db"}},
+		{[]string{"w"}, []string{"web"}},
+		{[]string{"-"}, []string{"-g", "-s", "--view", "-V"}},
+		{[]string{"--v"}, []string{"--view"}},
+		{[]string{"-g", ""}, []string{"Prod", "Staging"}},
+		{[]string{"--view", "D"}, []string{"DBs"}},
+		{[]string{"-s", ""}, nil},
+		{[]string{"-V", "d"}, []string{"db"}},
+		{[]string{"-g", "Prod", ""}, []string{"completion", "lint", "1", "web", "db"}},
+		{[]string{"-g=Prod", "1"}, []string{"1"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"web", ""}, nil},
+		{[]string{"lint", ""}, nil},
+		{[]string{"lint", "--"}, []string{"--json", "--fail-on"}},
+		{[]string{"lint", "--fail-on", ""}, []string{"error"}},
+		{[]string{"completion", "bash", ""}, nil},
+	}
+	for _, tt := range tests {
+		got := values(Complete(testSpec(), tt.words))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestHosts(t *testing.T) {
+	hosts := []host.Host{
+		{ShortName: "db", MenuNumber: 2, DescText: "Database"},
+		{ShortName: "web", MenuNumber: 1, DescText: "Web server"},
+	}
+	want := []Candidate{
+		{"1", "web: Web server"},
+		{"2", "db: Database"},
+		{"web", "Web server"},
+		{"db", "Database"},
+	}
+	if got := Hosts(hosts); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCandidateString(t *testing.T) {
+	if s := (Candidate{"web", "Web server"}).String(); s != "web\tWeb server" {
+		t.Errorf("unexpected %q", s)
+	}
+	if s := (Candidate{Value: "bash"}).String(); s != "bash" {
+		t.Errorf("unexpected %q", s)
+	}
+}
+
+func TestScript(t *testing.T) {
+	for _, shell := range Shells {
+		script, err := Script(shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(script, "ssh-menu __complete") || !strings.Contains(script, "--print") {
+			t.Errorf("%s script does not call __complete and --print", shell)
+		}
+	}
+	if _, err := Script("tcsh"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
@@ -0,0 +1,27 @@
+# bash completion for ssh-menu
+#
+# Load it with:  source <(ssh-menu completion bash)
+#
+# Ctrl-X opens the menu and inserts the chosen host's alias at the cursor.
+# Set SSH_MENU_WIDGET_KEY to another readline key sequence before loading
+# to change the key, or to an empty string to leave it unbound.
+
+_ssh_menu() {
+    local IFS=$'\n'
+    local -a lines
+    lines=($(ssh-menu __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    COMPREPLY=("${lines[@]%%$'\t'*}")
+}
+complete -F _ssh_menu ssh-menu
+
+__ssh_menu_widget() {
+    local alias
+    alias=$(ssh-menu --print --inline </dev/tty) || return
+    READLINE_LINE="${READLINE_LINE:0:READLINE_POINT}${alias}${READLINE_LINE:READLINE_POINT}"
+    READLINE_POINT=$((READLINE_POINT + ${#alias}))
+}
+__ssh_menu_key=${SSH_MENU_WIDGET_KEY-'\C-x'}
+if [[ $- == *i* && -n $__ssh_menu_key ]]; then
+    bind -x "\"$__ssh_menu_key\": __ssh_menu_widget"
+fi
+unset __ssh_menu_key
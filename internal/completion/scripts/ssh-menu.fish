@@ -0,0 +1,27 @@
+# fish completion for ssh-menu
+#
+# Load it with:  ssh-menu completion fish | source
+# or save it as ~/.config/fish/completions/ssh-menu.fish.
+#
+# Ctrl-X opens the menu and inserts the chosen host's alias at the cursor.
+# Set SSH_MENU_WIDGET_KEY to another bind key before loading to change the
+# key, or to an empty string to leave it unbound.
+
+function __ssh_menu_complete
+    set -l words (commandline -opc) (commandline -ct)
+    ssh-menu __complete $words[2..-1] 2>/dev/null
+end
+complete -c ssh-menu -f -a '(__ssh_menu_complete)'
+
+function __ssh_menu_widget
+    set -l alias (ssh-menu --print --inline </dev/tty)
+    and commandline -i -- $alias
+    commandline -f repaint
+end
+
+set -l key \cx
+set -q SSH_MENU_WIDGET_KEY; and set key $SSH_MENU_WIDGET_KEY
+if test -n "$key"
+    bind $key __ssh_menu_widget
+    bind -M insert $key __ssh_menu_widget 2>/dev/null
+end
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// Annotation kinds reported by ParseAnnotation.
+const (
+	AnnotationMenu   = host.AnnotationMenu
+	AnnotationGroup  = host.AnnotationGroup
+	AnnotationIP     = host.AnnotationIP
+	AnnotationTag    = host.AnnotationTag
+	AnnotationPinned = host.AnnotationPinned
+)
+
+// Annotation is an ssh-menu comment describing the Host line that follows
+// it, such as "# Menu 3: Web server" or "# Group: Production".
+type Annotation struct {
+	Kind string
+	// Number is the explicit menu number of a Menu annotation, or 0.
+	Number int
+	// Value is the description, group, IP or tag text.
+	Value string
+}
+
+// ParseAnnotation recognises an ssh-menu annotation in a trimmed config line.
+func ParseAnnotation(line string) (Annotation, bool) {
+	if m := reMenu.FindStringSubmatch(line); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return Annotation{Kind: AnnotationMenu, Number: n, Value: strings.TrimSpace(m[2])}, true
+	}
+	if m := reIP.FindStringSubmatch(line); m != nil {
+		return Annotation{Kind: AnnotationIP, Value: strings.TrimSpace(m[1])}, true
+	}
+	if m := reGroup.FindStringSubmatch(line); m != nil {
+		return Annotation{Kind: AnnotationGroup, Value: strings.TrimSpace(m[1])}, true
+	}
+	if m := reTag.FindStringSubmatch(line); m != nil {
+		return Annotation{Kind: AnnotationTag, Value: strings.TrimSpace(m[1])}, true
+	}
+	if rePinned.MatchString(line) {
+		return Annotation{Kind: AnnotationPinned}, true
+	}
+	return Annotation{}, false
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// cacheVersion is stored in every cache entry. Bump it whenever the parser
+// output changes so entries written by older versions are ignored.
+const cacheVersion = 3
+
+// Cache keeps the parse results of config files on disk so unchanged files
+// are not parsed again. Each file has its own entry, validated against the
+// file's path, size and modification time, falling back to its content
+// hash when those differ, so editing one file only invalidates that file.
+// Entries of removed files and of older versions are pruned after a load.
+type Cache struct {
+	dir string
+
+	// missed, if set, is called with the path of every file that had to
+	// be parsed.
+	missed func(path string)
+}
+
+// cacheEntry is the on-disk form of one cached file.
+type cacheEntry struct {
+	Version  int         `json:"version"`
+	Path     string      `json:"path"`
+	Size     int64       `json:"size"`
+	ModTime  int64       `json:"mtime"`
+	Hash     string      `json:"sha256"`
+	Hosts    []host.Host `json:"hosts"`
+	Includes []include   `json:"includes,omitempty"`
+}
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/ssh-menu, falling back to
+// ~/.cache when XDG_CACHE_HOME is unset.
+func DefaultCacheDir() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "ssh-menu")
+}
+
+// NewCache returns a cache storing its entries in dir.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// parse returns the parse result for the file at path, from the cache when
+// the file is unchanged. A file whose size and modification time match its
+// entry is taken as unchanged without reading it; otherwise its content
+// hash decides, so a file that was only touched is not parsed again. A nil
+// cache always parses.
+func (c *Cache) parse(path string) (parsedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return parsedFile{}, err
+	}
+	defer f.Close()
+	if c == nil {
+		return parseConfig(f, path)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return parsedFile{}, err
+	}
+	key := cacheEntry{
+		Version: cacheVersion,
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+	}
+	e, found := c.lookup(key)
+	if found && e.Size == key.Size && e.ModTime == key.ModTime {
+		return parsedFile{Hosts: e.Hosts, Includes: e.Includes}, nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return parsedFile{}, err
+	}
+	sum := sha256.Sum256(data)
+	key.Hash = hex.EncodeToString(sum[:])
+	if found && e.Hash == key.Hash {
+		// Only the metadata changed; record it so the next load skips the
+		// hash again.
+		key.Hosts, key.Includes = e.Hosts, e.Includes
+		_ = c.store(key)
+		return parsedFile{Hosts: e.Hosts, Includes: e.Includes}, nil
+	}
+
+	if c.missed != nil {
+		c.missed(path)
+	}
+	pf, err := parseConfig(bytes.NewReader(data), path)
+	if err != nil {
+		return parsedFile{}, err
+	}
+	key.Hosts, key.Includes = pf.Hosts, pf.Includes
+	// The cache is only an optimisation; failing to write it is not an error.
+	_ = c.store(key)
+	return pf, nil
+}
+
+// entryPath returns where the entry for the config file at path is kept.
+func (c *Cache) entryPath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// lookup returns the stored entry for key.Path if it was written by this
+// version of the cache. The caller compares its size, modification time
+// and hash with the file.
+func (c *Cache) lookup(key cacheEntry) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.entryPath(key.Path))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return cacheEntry{}, false
+	}
+	if e.Version != key.Version || e.Path != key.Path {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// store writes e atomically, so concurrent instances never read a
+// half-written entry.
+func (c *Cache) store(e cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.entryPath(e.Path))
+}
+
+// prune removes the entries of files that a load did not read, given the
+// paths it did read, when their file is gone or an older version wrote
+// them. Entries of other existing files are kept, as configs loaded with
+// other paths share the cache.
+func (c *Cache) prune(read []string) {
+	if c == nil {
+		return
+	}
+	keep := make(map[string]bool, len(read))
+	for _, path := range read {
+		keep[c.entryPath(path)] = true
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, de := range entries {
+		name := filepath.Join(c.dir, de.Name())
+		// Dot files are entries still being written.
+		if keep[name] || de.IsDir() || strings.HasPrefix(de.Name(), ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		var e cacheEntry
+		if json.Unmarshal(data, &e) == nil && e.Version == cacheVersion {
+			if _, err := os.Stat(e.Path); !os.IsNotExist(err) {
+				continue
+			}
+		}
+		os.Remove(name)
+	}
+}
@@ -0,0 +1,329 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// writeConfigTree creates a main config that includes extra.conf, plus two
+// files in config.d, and returns the main config path.
+func writeConfigTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"config": `# Menu: Main host
+Host main-01
+
+Include extra.conf
+`,
+		"extra.conf": `# Menu: Extra host
+Host extra-01
+`,
+		"config.d/a": `# Menu: Host A
+Host a-01
+`,
+		"config.d/b": `# Menu: Host B
+Host b-01
+`,
+	}
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, name), content)
+	}
+	return filepath.Join(dir, "config")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadCounting reads the config tree and returns the base names of the
+// files that had to be parsed.
+func loadCounting(t *testing.T, configPath string, cache *Cache) ([]host.Host, []string) {
+	t.Helper()
+	var parsed []string
+	cache.missed = func(path string) { parsed = append(parsed, filepath.Base(path)) }
+	hosts, _, err := ReadConfigFilesCached(configPath, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(parsed)
+	return hosts, parsed
+}
+
+func hostNames(hosts []host.Host) []string {
+	names := make([]string, len(hosts))
+	for i, h := range hosts {
+		names[i] = h.ShortName
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCache_UnchangedFilesAreNotParsed(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+
+	first, parsed := loadCounting(t, configPath, cache)
+	if want := []string{"a", "b", "config", "extra.conf"}; !equalStrings(parsed, want) {
+		t.Errorf("first load parsed %v, want %v", parsed, want)
+	}
+
+	second, parsed := loadCounting(t, configPath, cache)
+	if len(parsed) != 0 {
+		t.Errorf("second load parsed %v, want nothing", parsed)
+	}
+	if !equalStrings(hostNames(first), hostNames(second)) {
+		t.Errorf("cached hosts %v differ from parsed hosts %v", hostNames(second), hostNames(first))
+	}
+}
+
+func TestCache_ChangedIncludeInvalidatesOnlyItself(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	writeFile(t, filepath.Join(filepath.Dir(configPath), "extra.conf"), `# Menu: Extra host
+Host extra-02
+`)
+	hosts, parsed := loadCounting(t, configPath, cache)
+	if want := []string{"extra.conf"}; !equalStrings(parsed, want) {
+		t.Errorf("parsed %v, want %v", parsed, want)
+	}
+	if want := []string{"main-01", "extra-02", "a-01", "b-01"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+}
+
+func TestCache_ChangedConfigDFileInvalidatesOnlyItself(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	writeFile(t, filepath.Join(filepath.Dir(configPath), "config.d", "b"), `# Menu: Host B
+Host b-01
+# Menu: Host C
+Host c-01
+`)
+	hosts, parsed := loadCounting(t, configPath, cache)
+	if want := []string{"b"}; !equalStrings(parsed, want) {
+		t.Errorf("parsed %v, want %v", parsed, want)
+	}
+	if len(hosts) != 5 {
+		t.Errorf("expected 5 hosts, got %v", hostNames(hosts))
+	}
+}
+
+func TestCache_ChangedMainConfigKeepsIncludesCached(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	writeFile(t, configPath, `Include extra.conf
+
+# Menu: Main host
+Host main-01
+`)
+	hosts, parsed := loadCounting(t, configPath, cache)
+	if want := []string{"config"}; !equalStrings(parsed, want) {
+		t.Errorf("parsed %v, want %v", parsed, want)
+	}
+	if want := []string{"extra-01", "main-01", "a-01", "b-01"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+}
+
+func TestCache_TouchedFileNotParsed(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	path := filepath.Join(filepath.Dir(configPath), "config.d", "a")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, parsed := loadCounting(t, configPath, cache); len(parsed) != 0 {
+		t.Errorf("expected the touched file to be matched by its hash, parsed %v", parsed)
+	}
+	// The new modification time is recorded, so the next load does not
+	// hash the file again.
+	e, ok := cache.lookup(cacheEntry{Version: cacheVersion, Path: path})
+	if !ok || e.ModTime != later.UnixNano() {
+		t.Errorf("expected the entry to record the new modification time, got %v", e.ModTime)
+	}
+}
+
+func TestCache_SameSizeChangedContentDetectedByHash(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	path := filepath.Join(filepath.Dir(configPath), "config.d", "a")
+	writeFile(t, path, `# Menu: Host X
+Host x-01
+`)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, parsed := loadCounting(t, configPath, cache)
+	if want := []string{"a"}; !equalStrings(parsed, want) {
+		t.Errorf("parsed %v, want %v", parsed, want)
+	}
+	if hosts[2].ShortName != "x-01" {
+		t.Errorf("expected x-01 from the edited file, got %v", hostNames(hosts))
+	}
+}
+
+func TestCache_RemovedIncludeDropsItsHosts(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cache := NewCache(t.TempDir())
+	loadCounting(t, configPath, cache)
+
+	if err := os.Remove(filepath.Join(filepath.Dir(configPath), "extra.conf")); err != nil {
+		t.Fatal(err)
+	}
+	hosts, parsed := loadCounting(t, configPath, cache)
+	if len(parsed) != 0 {
+		t.Errorf("parsed %v, want nothing", parsed)
+	}
+	if want := []string{"main-01", "a-01", "b-01"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+}
+
+func TestCache_PrunesEntries(t *testing.T) {
+	configPath := writeConfigTree(t)
+	cacheDir := t.TempDir()
+	cache := NewCache(cacheDir)
+	// Another config sharing the cache keeps its entry.
+	other := filepath.Join(t.TempDir(), "other")
+	writeFile(t, other, "Host other-01\n")
+	if _, _, err := ReadConfigFilesCached(other, cache); err != nil {
+		t.Fatal(err)
+	}
+	// An entry written by an older version is dropped even though its
+	// file exists.
+	stale := filepath.Join(t.TempDir(), "stale")
+	writeFile(t, stale, "Host stale-01\n")
+	if err := cache.store(cacheEntry{Version: cacheVersion - 1, Path: stale}); err != nil {
+		t.Fatal(err)
+	}
+	loadCounting(t, configPath, cache)
+
+	if err := os.Remove(filepath.Join(filepath.Dir(configPath), "extra.conf")); err != nil {
+		t.Fatal(err)
+	}
+	loadCounting(t, configPath, cache)
+
+	var want []string
+	for _, path := range []string{
+		configPath,
+		filepath.Join(filepath.Dir(configPath), "config.d", "a"),
+		filepath.Join(filepath.Dir(configPath), "config.d", "b"),
+		other,
+	} {
+		want = append(want, filepath.Base(cache.entryPath(path)))
+	}
+	sort.Strings(want)
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("expected entries %v, got %v", want, got)
+	}
+}
+
+func TestReadConfigFiles_IncludeOfConfigDNotDuplicated(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config"), `Include config.d/*
+
+# Menu: Main host
+Host main-01
+`)
+	writeFile(t, filepath.Join(dir, "config.d", "a"), `# Menu: Host A
+Host a-01
+`)
+
+	hosts, err := ReadConfigFiles(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a-01", "main-01"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+}
+
+func TestReadConfigFiles_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config"), `Include other
+# Menu: Main host
+Host main-01
+`)
+	writeFile(t, filepath.Join(dir, "other"), `Include config
+# Menu: Other host
+Host other-01
+`)
+
+	hosts, err := ReadConfigFiles(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"other-01", "main-01"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+}
+
+func TestReadConfigFiles_IncludeInsideHostBlock(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config"), `# Menu: Web
+Host web
+    Include extra
+    User deploy
+
+# Menu: Db
+Host db
+`)
+	writeFile(t, filepath.Join(dir, "extra"), `# Menu: Extra
+Host extra
+`)
+	hosts, err := ReadConfigFiles(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"web", "extra", "db"}; !equalStrings(hostNames(hosts), want) {
+		t.Errorf("hosts %v, want %v", hostNames(hosts), want)
+	}
+	if hosts[0].User != "deploy" {
+		t.Errorf("expected web to keep User deploy, got %q", hosts[0].User)
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// UnifiedDiff returns a unified diff between the old and new content of the
+// file at path, or "" if they are equal. The edits made by this package
+// touch one host at a time, so the diff is a single hunk spanning from the
+// first changed line to the last.
+func UnifiedDiff(path string, old, new []byte) string {
+	a, b := splitLines(string(old)), splitLines(string(new))
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	start := max(prefix-diffContext, 0)
+	endA := min(len(a)-suffix+diffContext, len(a))
+	endB := min(len(b)-suffix+diffContext, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", path, path)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(start, endA-start), hunkRange(start, endB-start))
+	for _, line := range a[start:prefix] {
+		writeDiffLine(&sb, ' ', line)
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		writeDiffLine(&sb, '-', line)
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		writeDiffLine(&sb, '+', line)
+	}
+	for _, line := range a[len(a)-suffix : endA] {
+		writeDiffLine(&sb, ' ', line)
+	}
+	return sb.String()
+}
+
+// splitLines splits s into lines, each keeping its newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange formats the start and length of a hunk. An empty range names
+// the line before it.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func writeDiffLine(sb *strings.Builder, op byte, line string) {
+	sb.WriteByte(op)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	tests := []struct {
+		name, new, want string
+	}{
+		{"equal", old, ""},
+		{"insert", "a\nb\nc\nd\nX\ne\nf\ng\nh\n", "--- f\n+++ f\n@@ -2,6 +2,7 @@\n b\n c\n d\n+X\n e\n f\n g\n"},
+		{"replace at start", "X\nb\nc\nd\ne\nf\ng\nh\n", "--- f\n+++ f\n@@ -1,4 +1,4 @@\n-a\n+X\n b\n c\n d\n"},
+		{"delete at end", "a\nb\nc\nd\ne\nf\ng\n", "--- f\n+++ f\n@@ -5,4 +5,3 @@\n e\n f\n g\n-h\n"},
+		{"no newline", "a\nb\nc\nd\ne\nf\ng\nh", "--- f\n+++ f\n@@ -5,4 +5,4 @@\n e\n f\n g\n-h\n+h\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		if got := UnifiedDiff("f", []byte(old), []byte(tt.new)); got != tt.want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestUnifiedDiffEmptyFile(t *testing.T) {
+	want := "--- f\n+++ f\n@@ -0,0 +1 @@\n+a\n"
+	if got := UnifiedDiff("f", nil, []byte("a\n")); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Change is an edit to a config file: its content before and after.
+// Edits only compute a Change; nothing is written until Write is called.
+type Change struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// Changed reports whether the edit changes the file.
+func (c Change) Changed() bool {
+	return !bytes.Equal(c.Old, c.New)
+}
+
+// File returns the path of the file the change applies to.
+func (c Change) File() string {
+	return c.Path
+}
+
+// Diff returns the change as a unified diff, or "" if nothing changed.
+func (c Change) Diff() string {
+	return UnifiedDiff(c.Path, c.Old, c.New)
+}
+
+// Write saves the new content with WriteFile if it differs from the old.
+func (c Change) Write() error {
+	if !c.Changed() {
+		return nil
+	}
+	return WriteFile(c.Path, c.Old, c.New, DefaultWriteOptions())
+}
+
+// editHost reads the config file at path and applies edit to the lines of
+// the file, passing the index of the Host line for alias.
+func editHost(path, alias string, edit func(lines []string, hostIdx int) ([]string, error)) (Change, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Change{}, fmt.Errorf("reading config file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	hostIdx := hostLineIndex(lines, alias)
+	if hostIdx == -1 {
+		return Change{}, fmt.Errorf("host '%s' not found in %s", alias, path)
+	}
+	lines, err = edit(lines, hostIdx)
+	if err != nil {
+		return Change{}, err
+	}
+	return Change{Path: path, Old: content, New: keepFormat(content, []byte(strings.Join(lines, "\n")))}, nil
+}
+
+// hostLineIndex returns the index of the first Host line for alias, or -1.
+func hostLineIndex(lines []string, alias string) int {
+	for i, line := range lines {
+		if m := reHostLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil && strings.TrimSpace(m[1]) == alias {
+			return i
+		}
+	}
+	return -1
+}
+
+// annotationLines returns the indexes of the annotations of the Host line
+// at hostIdx with the given kind. Annotations apply to the next Host line,
+// so the search stops at the previous one.
+func annotationLines(lines []string, hostIdx int, kind string) []int {
+	var found []int
+	for i := hostIdx - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if reHostLine.MatchString(trimmed) {
+			break
+		}
+		if a, ok := ParseAnnotation(trimmed); ok && a.Kind == kind {
+			found = append([]int{i}, found...)
+		}
+	}
+	return found
+}
+
+// insertAnnotation inserts line among the annotations of the Host line at
+// hostIdx: after the last annotation of kind after, if any, otherwise
+// right above the Host line.
+func insertAnnotation(lines []string, hostIdx int, after []string, line string) []string {
+	at := hostIdx
+	for i := hostIdx - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		if a, ok := ParseAnnotation(trimmed); ok && sliceContains(after, a.Kind) {
+			at = i + 1
+			break
+		}
+	}
+	out := make([]string, 0, len(lines)+1)
+	out = append(out, lines[:at]...)
+	out = append(out, indentOf(lines[hostIdx])+line)
+	return append(out, lines[at:]...)
+}
+
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func removeLines(lines []string, idx []int) []string {
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !sliceContainsInt(idx, i) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func sliceContainsInt(slice []int, n int) bool {
+	for _, v := range slice {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPinned adds or removes the # Pinned comment of a host.
+func SetPinned(path, alias string, pin bool) (Change, error) {
+	return editHost(path, alias, func(lines []string, hostIdx int) ([]string, error) {
+		if pin {
+			return addPinComment(lines, hostIdx), nil
+		}
+		return removePinComment(lines, hostIdx), nil
+	})
+}
+
+// AddGroup adds a # Group comment for group to a host, unless the host is
+// already in the group.
+func AddGroup(path, alias, group string) (Change, error) {
+	return editHost(path, alias, func(lines []string, hostIdx int) ([]string, error) {
+		for _, i := range annotationLines(lines, hostIdx, AnnotationGroup) {
+			if a, _ := ParseAnnotation(strings.TrimSpace(lines[i])); a.Value == group {
+				return lines, nil
+			}
+		}
+		return insertAnnotation(lines, hostIdx, []string{AnnotationMenu, AnnotationGroup}, "# Group: "+group), nil
+	})
+}
+
+// RemoveGroup removes the # Group comments for group from a host.
+func RemoveGroup(path, alias, group string) (Change, error) {
+	return editHost(path, alias, func(lines []string, hostIdx int) ([]string, error) {
+		var remove []int
+		for _, i := range annotationLines(lines, hostIdx, AnnotationGroup) {
+			if a, _ := ParseAnnotation(strings.TrimSpace(lines[i])); a.Value == group {
+				remove = append(remove, i)
+			}
+		}
+		return removeLines(lines, remove), nil
+	})
+}
+
+// SetDescription replaces the description in a host's # Menu comment,
+// keeping its menu number.
+func SetDescription(path, alias, desc string) (Change, error) {
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		return Change{}, fmt.Errorf("description must not be empty")
+	}
+	return editHost(path, alias, func(lines []string, hostIdx int) ([]string, error) {
+		idx := menuCommentIndex(lines, hostIdx)
+		if idx < 0 {
+			return nil, fmt.Errorf("host '%s' has no # Menu comment in %s", alias, path)
+		}
+		a, _ := ParseAnnotation(strings.TrimSpace(lines[idx]))
+		if a.Value == desc {
+			return lines, nil
+		}
+		menu := "# Menu: "
+		if a.Number > 0 {
+			menu = fmt.Sprintf("# Menu %d: ", a.Number)
+		}
+		lines[idx] = indentOf(lines[idx]) + menu + desc
+		return lines, nil
+	})
+}
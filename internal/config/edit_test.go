@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editConfig = `# Menu 1: Web server
+# Group: Production
+Host web-01
+    HostName 10.0.1.5
+
+# Menu 2: Database
+Host db-01
+    HostName 10.0.2.5
+`
+
+func writeEditConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(editConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAddGroup(t *testing.T) {
+	path := writeEditConfig(t)
+	tests := []struct {
+		alias, group, want string
+	}{
+		{"web-01", "Web", "# Menu 1: Web server\n# Group: Production\n# Group: Web\nHost web-01\n"},
+		{"db-01", "Production", "# Menu 2: Database\n# Group: Production\nHost db-01\n"},
+	}
+	for _, tt := range tests {
+		c, err := AddGroup(path, tt.alias, tt.group)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(c.New), tt.want) {
+			t.Errorf("AddGroup(%s, %s): expected\n%s\nin\n%s", tt.alias, tt.group, tt.want, c.New)
+		}
+	}
+
+	c, err := AddGroup(path, "web-01", "Production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Changed() {
+		t.Error("adding an existing group should not change the file")
+	}
+}
+
+func TestRemoveGroup(t *testing.T) {
+	path := writeEditConfig(t)
+	c, err := RemoveGroup(path, "web-01", "Production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "# Menu 1: Web server\nHost web-01\n"; !strings.Contains(string(c.New), want) {
+		t.Errorf("expected\n%s\nin\n%s", want, c.New)
+	}
+
+	// A group of another host is left alone.
+	c, err = RemoveGroup(path, "db-01", "Production")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Changed() {
+		t.Error("removing a group the host is not in should not change the file")
+	}
+}
+
+func TestSetDescription(t *testing.T) {
+	path := writeEditConfig(t)
+	c, err := SetDescription(path, "db-01", "  Primary database ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "# Menu 2: Primary database\nHost db-01\n"; !strings.Contains(string(c.New), want) {
+		t.Errorf("expected\n%s\nin\n%s", want, c.New)
+	}
+
+	c, err = SetDescription(path, "db-01", "Database")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Changed() {
+		t.Error("setting the same description should not change the file")
+	}
+
+	if _, err := SetDescription(path, "db-01", " "); err == nil {
+		t.Error("expected error for empty description")
+	}
+}
+
+func TestEditHostNotFound(t *testing.T) {
+	path := writeEditConfig(t)
+	if _, err := SetPinned(path, "nope", true); err == nil {
+		t.Error("expected error for unknown host")
+	}
+}
+
+func TestChangeWrite(t *testing.T) {
+	path := writeEditConfig(t)
+	c, err := SetPinned(path, "db-01", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != editConfig {
+		t.Fatal("computing a change should not write the file")
+	}
+	if err := c.Write(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(c.New) {
+		t.Errorf("expected file to be written, got\n%s", got)
+	}
+}
+
+func TestAddGroup_KeepsCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := strings.ReplaceAll(editConfig, "\n", "\r\n")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := AddGroup(path, "db-01", "Database")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "# Menu 2: Database\r\n# Group: Database\r\nHost db-01\r\n"; !strings.Contains(string(c.New), want) {
+		t.Errorf("expected %q in %q", want, c.New)
+	}
+}
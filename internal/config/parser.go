@@ -22,26 +22,64 @@ var (
 	reMenu     = regexp.MustCompile(`^#\s*Menu(?:\s+(\d+))?:\s*(.+)$`)
 	reIP       = regexp.MustCompile(`^#\s*IP:\s*(.+)$`)
 	reGroup    = regexp.MustCompile(`^#\s*Group:\s*(.+)$`)
+	reTag      = regexp.MustCompile(`^#\s*Tag:\s*(.+)$`)
 	rePinned   = regexp.MustCompile(`^#\s*Pinned\s*$`)
+	reInclude  = regexp.MustCompile(`(?i)^Include\s+(.+)$`)
 )
 
+// maxIncludeDepth limits nested Include directives, as ssh does.
+const maxIncludeDepth = 16
+
+// include is an Include directive: its patterns and the number of hosts
+// parsed before it, which is where the included hosts belong.
+type include struct {
+	Patterns []string
+	At       int
+}
+
+// parsedFile is the result of parsing a single config file, with its
+// Include directives left unresolved.
+type parsedFile struct {
+	Hosts    []host.Host
+	Includes []include
+}
+
 // pendingMeta holds annotations that appear before a Host line.
 type pendingMeta struct {
-	descText   string
-	menuNumber int
-	ip         string
-	groups     []string
-	pinned     bool
+	descText    string
+	menuNumber  int
+	ip          string
+	groups      []string
+	tags        map[string]string
+	pinned      bool
+	annotations []host.Annotation
+}
+
+// note records the position of an annotation.
+func (p *pendingMeta) note(kind, value string, line int) {
+	p.annotations = append(p.annotations, host.Annotation{Kind: kind, Value: value, Line: line})
 }
 
 // ParseReader parses SSH config from a reader and returns host entries.
+// Include directives are not followed.
 func ParseReader(r io.Reader, sourceFile string) ([]host.Host, error) {
+	pf, err := parseConfig(r, sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return pf.Hosts, nil
+}
+
+func parseConfig(r io.Reader, sourceFile string) (parsedFile, error) {
 	var hosts []host.Host
+	var includes []include
 	var current *host.Host
 	var pending pendingMeta
 
 	scanner := bufio.NewScanner(r)
+	lineNum := 0
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
@@ -63,13 +101,17 @@ func ParseReader(r io.Reader, sourceFile string) ([]host.Host, error) {
 
 			// Start new host, applying any buffered pending metadata
 			current = &host.Host{
-				ShortName:  hostName,
-				Groups:     pending.groups,
-				DescText:   pending.descText,
-				MenuNumber: pending.menuNumber,
-				IP:         pending.ip,
-				Pinned:     pending.pinned,
-				SourceFile: sourceFile,
+				ShortName:   hostName,
+				Groups:      pending.groups,
+				Tags:        pending.tags,
+				DescText:    pending.descText,
+				MenuNumber:  pending.menuNumber,
+				IP:          pending.ip,
+				Pinned:      pending.pinned,
+				SourceFile:  sourceFile,
+				Line:        lineNum,
+				EndLine:     lineNum,
+				Annotations: pending.annotations,
 			}
 			if current.Groups == nil {
 				current.Groups = []string{}
@@ -84,15 +126,17 @@ func ParseReader(r io.Reader, sourceFile string) ([]host.Host, error) {
 				var err error
 				num, err = strconv.Atoi(m[1])
 				if err != nil {
-					return nil, fmt.Errorf("invalid menu number: %s", m[1])
+					return parsedFile{}, fmt.Errorf("invalid menu number: %s", m[1])
 				}
 			}
 			pending.menuNumber = num
 			pending.descText = strings.TrimSpace(m[2])
+			pending.note(AnnotationMenu, pending.descText, lineNum)
 			continue
 		}
 		if m := reIP.FindStringSubmatch(line); m != nil {
 			pending.ip = strings.TrimSpace(m[1])
+			pending.note(AnnotationIP, pending.ip, lineNum)
 			continue
 		}
 		if m := reGroup.FindStringSubmatch(line); m != nil {
@@ -100,16 +144,44 @@ func ParseReader(r io.Reader, sourceFile string) ([]host.Host, error) {
 			if !sliceContains(pending.groups, g) {
 				pending.groups = append(pending.groups, g)
 			}
+			pending.note(AnnotationGroup, g, lineNum)
+			continue
+		}
+		if m := reTag.FindStringSubmatch(line); m != nil {
+			pending.note(AnnotationTag, strings.TrimSpace(m[1]), lineNum)
+			key, value := parseTag(m[1])
+			if key != "" {
+				if pending.tags == nil {
+					pending.tags = make(map[string]string)
+				}
+				pending.tags[key] = value
+			}
 			continue
 		}
 		if rePinned.MatchString(line) {
 			pending.pinned = true
+			pending.note(AnnotationPinned, "", lineNum)
+			continue
+		}
+
+		if m := reInclude.FindStringSubmatch(line); m != nil {
+			// Included hosts follow the block the directive appears in. As
+			// in ssh, the block goes on after the Include, so the
+			// directives below it still belong to the current host.
+			at := len(hosts)
+			if current != nil && current.ShortName != "" && current.DescText != "" {
+				at++
+			}
+			includes = append(includes, include{Patterns: strings.Fields(m[1]), At: at})
 			continue
 		}
 
 		if current == nil {
 			continue
 		}
+		if !strings.HasPrefix(line, "#") {
+			current.EndLine = lineNum
+		}
 		if m := reHostname.FindStringSubmatch(line); m != nil {
 			current.LongName = strings.TrimSpace(m[1])
 		} else if m := reUser.FindStringSubmatch(line); m != nil {
@@ -125,17 +197,41 @@ func ParseReader(r io.Reader, sourceFile string) ([]host.Host, error) {
 		hosts = append(hosts, *current)
 	}
 
-	return hosts, nil
+	return parsedFile{Hosts: hosts, Includes: includes}, scanner.Err()
 }
 
-// ReadConfigFiles reads all SSH config files (main + config.d).
+// ReadConfigFiles reads all SSH config files (main + config.d), following
+// Include directives.
 func ReadConfigFiles(configPath string) ([]host.Host, error) {
-	mainHosts, err := parseFile(configPath)
+	hosts, _, err := ReadConfigFilesCached(configPath, nil)
+	return hosts, err
+}
+
+// ReadConfigFilesCached is like ReadConfigFiles but reuses parse results
+// from cache for files that have not changed. A nil cache parses every file.
+// It also returns the path of every config file it read, in the order read.
+func ReadConfigFilesCached(configPath string, cache *Cache) ([]host.Host, []string, error) {
+	l := &loader{cache: cache, baseDir: filepath.Dir(configPath), seen: make(map[string]bool)}
+	hosts, err := l.loadAll(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	cache.prune(l.order)
+	return hosts, l.order, nil
+}
+
+// ConfigDir returns the config.d directory that accompanies configPath.
+func ConfigDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "config.d")
+}
+
+func (l *loader) loadAll(configPath string) ([]host.Host, error) {
+	mainHosts, err := l.load(configPath, 0)
 	if err != nil {
 		return nil, fmt.Errorf("error reading main config: %w", err)
 	}
 
-	configDirPath := filepath.Join(filepath.Dir(configPath), "config.d")
+	configDirPath := ConfigDir(configPath)
 	dirInfo, err := os.Stat(configDirPath)
 	if os.IsNotExist(err) || (err == nil && !dirInfo.IsDir()) {
 		return mainHosts, nil
@@ -154,7 +250,7 @@ func ReadConfigFiles(configPath string) ([]host.Host, error) {
 			continue
 		}
 		filePath := filepath.Join(configDirPath, file.Name())
-		additional, err := parseFile(filePath)
+		additional, err := l.load(filePath, 0)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Error reading config file %s: %v\n", filePath, err)
 			continue
@@ -165,13 +261,83 @@ func ReadConfigFiles(configPath string) ([]host.Host, error) {
 	return allHosts, nil
 }
 
-func parseFile(path string) ([]host.Host, error) {
-	f, err := os.Open(path)
+// loader reads config files and the files they include, parsing each file
+// at most once.
+type loader struct {
+	cache   *Cache
+	baseDir string
+	seen    map[string]bool
+	order   []string
+}
+
+func (l *loader) load(path string, depth int) ([]host.Host, error) {
+	path = filepath.Clean(path)
+	if l.seen[path] {
+		return nil, nil
+	}
+	l.seen[path] = true
+	l.order = append(l.order, path)
+
+	pf, err := l.cache.parse(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	return ParseReader(f, path)
+	if len(pf.Includes) == 0 {
+		return pf.Hosts, nil
+	}
+
+	var hosts []host.Host
+	next := 0
+	for _, inc := range pf.Includes {
+		hosts = append(hosts, pf.Hosts[next:inc.At]...)
+		next = inc.At
+		if depth >= maxIncludeDepth {
+			fmt.Fprintf(os.Stderr, "Warning: Include nested too deeply in %s\n", path)
+			continue
+		}
+		for _, p := range l.expand(inc.Patterns) {
+			included, err := l.load(p, depth+1)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Error reading included file %s: %v\n", p, err)
+				continue
+			}
+			hosts = append(hosts, included...)
+		}
+	}
+	return append(hosts, pf.Hosts[next:]...), nil
+}
+
+// expand resolves Include patterns to files. Relative patterns are taken
+// from the main config's directory, as ssh does for ~/.ssh/config.
+func (l *loader) expand(patterns []string) []string {
+	var paths []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				pattern = filepath.Join(home, pattern[2:])
+			}
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(l.baseDir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Invalid Include pattern %s: %v\n", pattern, err)
+			continue
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths
+}
+
+// parseTag splits a "key=value" tag annotation. A bare key yields an empty value.
+func parseTag(s string) (string, string) {
+	key, value, _ := strings.Cut(s, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value)
 }
 
 func sliceContains(slice []string, s string) bool {
@@ -3,6 +3,8 @@ package config
 import (
 	"strings"
 	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
 )
 
 func TestParseReader_BasicHost(t *testing.T) {
@@ -130,3 +132,88 @@ Host real
 	if len(hosts) != 1 { t.Fatalf("expected 1 host (skipping wildcard), got %d", len(hosts)) }
 	if hosts[0].ShortName != "real" { t.Errorf("expected 'real', got '%s'", hosts[0].ShortName) }
 }
+
+func TestParseReader_Tags(t *testing.T) {
+	input := `# Menu: Tagged
+# Tag: env=prod
+# Tag: role = db
+# Tag: critical
+Host tagged
+    HostName 10.0.1.1
+`
+	hosts, err := ParseReader(strings.NewReader(input), "test.config")
+	if err != nil { t.Fatalf("unexpected error: %v", err) }
+	tags := hosts[0].Tags
+	if tags["env"] != "prod" { t.Errorf("env: expected prod, got '%s'", tags["env"]) }
+	if tags["role"] != "db" { t.Errorf("role: expected db, got '%s'", tags["role"]) }
+	if v, ok := tags["critical"]; !ok || v != "" { t.Errorf("critical: expected bare tag, got '%s' (%v)", v, ok) }
+}
+
+func TestParseReader_LineNumbers(t *testing.T) {
+	input := `Host *
+    User admin
+
+# Menu 1: Web server
+# Group: Production
+# IP: 203.0.113.5
+Host web-01
+    HostName 10.0.1.5
+    # a note
+    Port 2222
+
+# Menu: Database
+Host db-01
+`
+	hosts, err := ParseReader(strings.NewReader(input), "test.config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	web, db := hosts[0], hosts[1]
+	if web.Line != 7 || web.EndLine != 10 {
+		t.Errorf("web-01: expected lines 7-10, got %d-%d", web.Line, web.EndLine)
+	}
+	if web.Location() != "test.config:7" {
+		t.Errorf("web-01: expected location test.config:7, got %s", web.Location())
+	}
+	want := []host.Annotation{
+		{Kind: AnnotationMenu, Value: "Web server", Line: 4},
+		{Kind: AnnotationGroup, Value: "Production", Line: 5},
+		{Kind: AnnotationIP, Value: "203.0.113.5", Line: 6},
+	}
+	if len(web.Annotations) != len(want) {
+		t.Fatalf("web-01: expected %v, got %v", want, web.Annotations)
+	}
+	for i := range want {
+		if web.Annotations[i] != want[i] {
+			t.Errorf("web-01: annotation %d: expected %v, got %v", i, want[i], web.Annotations[i])
+		}
+	}
+	if db.Line != 13 || db.EndLine != 13 || db.AnnotationLine(AnnotationMenu) != 12 {
+		t.Errorf("db-01: expected Host line 13 and Menu line 12, got %d-%d, %d",
+			db.Line, db.EndLine, db.AnnotationLine(AnnotationMenu))
+	}
+}
+
+func TestParseReader_IncludeInsideHostBlock(t *testing.T) {
+	input := `# Menu: Web server
+Host web
+    HostName 10.0.0.1
+    Include extra
+    User deploy
+    Port 2222
+`
+	hosts, err := ParseReader(strings.NewReader(input), "test.config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+	h := hosts[0]
+	if h.LongName != "10.0.0.1" || h.User != "deploy" || h.Port != "2222" {
+		t.Errorf("expected the directives after Include to be kept, got %s %s %s", h.LongName, h.User, h.Port)
+	}
+	if h.EndLine != 6 {
+		t.Errorf("expected the block to end on line 6, got %d", h.EndLine)
+	}
+}
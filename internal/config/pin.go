@@ -1,8 +1,6 @@
 package config
 
 import (
-	"fmt"
-	"os"
 	"regexp"
 	"strings"
 )
@@ -14,35 +12,11 @@ var reGroupLine = regexp.MustCompile(`^#\s*Group:`)
 
 // TogglePin adds or removes a # Pinned comment for a host in a config file.
 func TogglePin(filePath string, hostAlias string, pin bool) error {
-	content, err := os.ReadFile(filePath)
+	c, err := SetPinned(filePath, hostAlias, pin)
 	if err != nil {
-		return fmt.Errorf("reading config file: %w", err)
+		return err
 	}
-
-	lines := strings.Split(string(content), "\n")
-	hostLineIdx := -1
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if m := reHostLine.FindStringSubmatch(trimmed); m != nil {
-			if strings.TrimSpace(m[1]) == hostAlias {
-				hostLineIdx = i
-				break
-			}
-		}
-	}
-
-	if hostLineIdx == -1 {
-		return fmt.Errorf("host '%s' not found in %s", hostAlias, filePath)
-	}
-
-	if pin {
-		lines = addPinComment(lines, hostLineIdx)
-	} else {
-		lines = removePinComment(lines, hostLineIdx)
-	}
-
-	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644)
+	return c.Write()
 }
 
 func addPinComment(lines []string, hostLineIdx int) []string {
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// WriteMenuNumbers rewrites the # Menu comments of hosts in a config file to
+// carry explicit numbers. numbers maps host aliases to their menu number.
+func WriteMenuNumbers(filePath string, numbers map[string]int) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	found := make(map[string]bool, len(numbers))
+	for i, line := range lines {
+		m := reHostLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		alias := strings.TrimSpace(m[1])
+		n, ok := numbers[alias]
+		if !ok {
+			continue
+		}
+		if idx := menuCommentIndex(lines, i); idx >= 0 {
+			lines[idx] = setMenuNumber(lines[idx], n)
+			found[alias] = true
+		}
+	}
+
+	for alias := range numbers {
+		if !found[alias] {
+			return fmt.Errorf("host '%s' has no # Menu comment in %s", alias, filePath)
+		}
+	}
+
+	return WriteFile(filePath, content, []byte(strings.Join(lines, "\n")), DefaultWriteOptions())
+}
+
+// menuCommentIndex returns the line of the # Menu comment that applies to
+// the Host line at hostLineIdx, or -1 if there is none. Annotations apply
+// to the next Host line, so the search stops at the previous one.
+func menuCommentIndex(lines []string, hostLineIdx int) int {
+	for i := hostLineIdx - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if reMenu.MatchString(trimmed) {
+			return i
+		}
+		if reHostLine.MatchString(trimmed) {
+			break
+		}
+	}
+	return -1
+}
+
+// setMenuNumber rewrites a # Menu comment line to carry number n, keeping
+// its indentation and description.
+func setMenuNumber(line string, n int) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	m := reMenu.FindStringSubmatch(strings.TrimSpace(line))
+	return fmt.Sprintf("%s# Menu %d: %s", indent, n, strings.TrimSpace(m[2]))
+}
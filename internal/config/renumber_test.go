@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMenuNumbers(t *testing.T) {
+	content := `# Menu: Web server
+# Group: Production
+Host web-01
+    HostName 10.0.1.5
+
+# Menu 3: Database
+Host db-01
+    HostName 10.0.1.6
+
+    # Menu: Cache
+Host cache-01
+`
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte(content), 0644)
+
+	if err := WriteMenuNumbers(path, map[string]int{"web-01": 1, "cache-01": 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	got := string(result)
+	for _, want := range []string{"# Menu 1: Web server\n", "# Menu 3: Database\n", "    # Menu 2: Cache\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in result:\n%s", want, got)
+		}
+	}
+
+	hosts, err := ParseReader(strings.NewReader(got), path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, h := range hosts {
+		if h.MenuNumber == 0 {
+			t.Errorf("expected %s to have an explicit number", h.ShortName)
+		}
+	}
+}
+
+func TestWriteMenuNumbers_StopsAtPreviousHost(t *testing.T) {
+	content := `# Menu: Web server
+Host web-01
+Host other
+`
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte(content), 0644)
+
+	if err := WriteMenuNumbers(path, map[string]int{"other": 1}); err == nil {
+		t.Error("expected error for host without its own # Menu comment")
+	}
+	result, _ := os.ReadFile(path)
+	if string(result) != content {
+		t.Errorf("file should be unchanged, got:\n%s", result)
+	}
+}
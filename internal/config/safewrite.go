@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBackups is how many backups of each config file are kept.
+const DefaultBackups = 5
+
+// ErrModified is returned when a file changed between reading it and
+// writing an edit of it.
+var ErrModified = errors.New("file changed since it was read; re-run to apply the edit to the new content")
+
+// WriteOptions control how WriteFile saves a config file.
+type WriteOptions struct {
+	// BackupDir is where copies of replaced files are kept. Empty keeps
+	// no backups.
+	BackupDir string
+	// Backups is how many copies of each file are kept in BackupDir, the
+	// most recent with suffix .1.
+	Backups int
+}
+
+// DefaultWriteOptions keeps DefaultBackups backups of each file in
+// $XDG_STATE_HOME/ssh-menu/backups, falling back to ~/.local/state when
+// XDG_STATE_HOME is unset.
+func DefaultWriteOptions() WriteOptions {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return WriteOptions{}
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return WriteOptions{BackupDir: filepath.Join(dir, "ssh-menu", "backups"), Backups: DefaultBackups}
+}
+
+// WriteFile replaces the config file at path with data, which is an edit
+// of old, the content the file had when it was read.
+//
+// The file is never left half-written: data goes to a temporary file in
+// the same directory, which is synced and renamed over the original. A
+// symlink is followed so the file it points to is replaced rather than
+// the link. The original's mode and owner are kept, and data is given the
+// original's line endings and trailing newline. If the file no longer
+// holds old, nothing is written and the error wraps ErrModified. Before
+// the file is replaced, its content is saved as a backup.
+func WriteFile(path string, old, data []byte, opts WriteOptions) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	current, err := os.ReadFile(target)
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if !bytes.Equal(current, old) {
+		return fmt.Errorf("%s: %w", path, ErrModified)
+	}
+	data = keepFormat(old, data)
+	if bytes.Equal(data, old) {
+		return nil
+	}
+	if err := backup(target, old, opts); err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeTemp(tmp, data, info); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return syncDir(filepath.Dir(target))
+}
+
+// writeTemp fills the temporary file and gives it the mode and owner of
+// the file it replaces.
+func writeTemp(tmp *os.File, data []byte, info os.FileInfo) error {
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := chown(tmp, info); err != nil {
+		return fmt.Errorf("keeping the owner of the file: %w", err)
+	}
+	return tmp.Sync()
+}
+
+// keepFormat gives data the line endings and trailing newline of old.
+// Edits split files on "\n", so lines they add to a CRLF file lack the
+// "\r" and the file would end up with mixed line endings.
+func keepFormat(old, data []byte) []byte {
+	if len(old) == 0 {
+		return data
+	}
+	newline := []byte("\n")
+	if n := bytes.Count(old, newline); n > 0 && bytes.Count(old, []byte("\r\n")) == n {
+		newline = []byte("\r\n")
+		data = bytes.ReplaceAll(data, newline, []byte("\n"))
+		data = bytes.ReplaceAll(data, []byte("\n"), newline)
+	}
+	switch endsWithNewline := bytes.HasSuffix(old, []byte("\n")); {
+	case endsWithNewline && !bytes.HasSuffix(data, []byte("\n")):
+		data = append(data, newline...)
+	case !endsWithNewline && bytes.HasSuffix(data, newline):
+		data = data[:len(data)-len(newline)]
+	}
+	return data
+}
+
+// backup saves content as the newest backup of the file at path, shifting
+// older backups up by one and dropping the oldest.
+func backup(path string, content []byte, opts WriteOptions) error {
+	if opts.BackupDir == "" || opts.Backups < 1 {
+		return nil
+	}
+	if err := os.MkdirAll(opts.BackupDir, 0700); err != nil {
+		return err
+	}
+	// The escaped path names the backup, so files with the same name in
+	// different directories do not share backups. Colons are escaped too
+	// for Windows drive letters.
+	base := filepath.Join(opts.BackupDir, strings.ReplaceAll(url.PathEscape(path), ":", "%3A"))
+	name := func(n int) string { return fmt.Sprintf("%s.%d", base, n) }
+	if err := os.Remove(name(opts.Backups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := opts.Backups - 1; n >= 1; n-- {
+		if err := os.Rename(name(n), name(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(name(1), content, 0600)
+}
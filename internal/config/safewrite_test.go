@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestMain points XDG_STATE_HOME at a temporary directory, so the backups
+// made by edits in tests stay out of the real state directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "ssh-menu-state-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("XDG_STATE_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func writeTestFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFile_KeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not kept on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	writeTestFile(t, path, "Host a\n", 0600)
+	if err := WriteFile(path, []byte("Host a\n"), []byte("Host b\n"), WriteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+	if got, _ := os.ReadFile(path); string(got) != "Host b\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestWriteFile_FollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real")
+	link := filepath.Join(dir, "config")
+	writeTestFile(t, target, "Host a\n", 0644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+	if err := WriteFile(link, []byte("Host a\n"), []byte("Host b\n"), WriteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected %s to still be a symlink", link)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "Host b\n" {
+		t.Errorf("expected the link target to be written, got %q", got)
+	}
+}
+
+func TestWriteFile_DetectsModification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeTestFile(t, path, "Host changed\n", 0644)
+	err := WriteFile(path, []byte("Host a\n"), []byte("Host b\n"), WriteOptions{})
+	if !errors.Is(err, ErrModified) {
+		t.Fatalf("expected ErrModified, got %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "Host changed\n" {
+		t.Errorf("expected the file to be left alone, got %q", got)
+	}
+}
+
+func TestWriteFile_Backups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	opts := WriteOptions{BackupDir: filepath.Join(dir, "backups"), Backups: 2}
+	writeTestFile(t, path, "v1\n", 0644)
+	for _, v := range []string{"v2\n", "v3\n", "v4\n"} {
+		old, _ := os.ReadFile(path)
+		if err := WriteFile(path, old, []byte(v), opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(opts.BackupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 backups, got %d", len(entries))
+	}
+	for i, want := range []string{"v3\n", "v2\n"} {
+		name := entries[i].Name()
+		got, _ := os.ReadFile(filepath.Join(opts.BackupDir, name))
+		if string(got) != want {
+			t.Errorf("backup %s: expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestKeepFormat(t *testing.T) {
+	tests := []struct {
+		name, old, data, want string
+	}{
+		{"lf", "a\nb\n", "a\nx\nb\n", "a\nx\nb\n"},
+		{"crlf", "a\r\nb\r\n", "a\r\nx\nb\r\n", "a\r\nx\r\nb\r\n"},
+		{"mixed left alone", "a\r\nb\n", "a\r\nx\nb\n", "a\r\nx\nb\n"},
+		{"add trailing newline", "a\n", "a\nb", "a\nb\n"},
+		{"drop trailing newline", "a\nb", "a\nx\nb\n", "a\nx\nb"},
+		{"crlf without trailing newline", "a\r\nb", "a\r\nb\nx\n", "a\r\nb\r\nx"},
+	}
+	for _, tt := range tests {
+		if got := string(keepFormat([]byte(tt.old), []byte(tt.data))); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
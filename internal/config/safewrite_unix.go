@@ -0,0 +1,47 @@
+//go:build !windows
+
+package config
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chown gives f the owner and group in info, when they differ from the
+// ones f was created with. A group the user cannot give the file, because
+// they are not a member of it, is left as created rather than failing the
+// write.
+func chown(f *os.File, info os.FileInfo) error {
+	want, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	created, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	have, ok := created.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if want.Uid != have.Uid {
+		return f.Chown(int(want.Uid), int(want.Gid))
+	}
+	if want.Gid != have.Gid {
+		if err := f.Chown(-1, int(want.Gid)); err != nil && !errors.Is(err, syscall.EPERM) {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncDir flushes a directory so a rename in it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package config
+
+import "os"
+
+// chown does nothing on Windows, where a replaced file takes the
+// permissions of its directory.
+func chown(f *os.File, info os.FileInfo) error {
+	return nil
+}
+
+// syncDir does nothing on Windows, which cannot sync a directory.
+func syncDir(dir string) error {
+	return nil
+}
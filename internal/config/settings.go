@@ -0,0 +1,132 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// View is a saved query shown as a tab in the view bar.
+type View struct {
+	Name  string
+	Query string
+}
+
+// KeyConfig holds keybinding settings: a named preset plus per-action
+// overrides mapping action names to key sequences.
+type KeyConfig struct {
+	Preset   string
+	Bindings map[string][]string
+}
+
+// Settings holds ssh-menu options declared as comments in the SSH config.
+type Settings struct {
+	Views     []View
+	Keys      KeyConfig
+	Columns   []string
+	MatchMode string
+
+	// StableNumbers keeps auto-assigned menu numbers across runs.
+	StableNumbers bool
+
+	// PinStore is where pins are saved: PinStoreConfig or PinStoreState.
+	PinStore string
+}
+
+// Pin stores. With PinStoreState, # Pinned comments are still read but
+// never written.
+const (
+	PinStoreConfig = "config"
+	PinStoreState  = "state"
+)
+
+var (
+	reView      = regexp.MustCompile(`^#\s*View:\s*(.+?)\s*=\s*(.+)$`)
+	reKeyPreset = regexp.MustCompile(`^#\s*KeyPreset:\s*(\S+)\s*$`)
+	reKey       = regexp.MustCompile(`^#\s*Key:\s*([\w-]+)\s*=\s*(.+)$`)
+	reColumns   = regexp.MustCompile(`^#\s*Columns:\s*(.+)$`)
+	reMatchMode = regexp.MustCompile(`^#\s*MatchMode:\s*(\S+)\s*$`)
+	reStable    = regexp.MustCompile(`^#\s*StableNumbers:\s*(\S+)\s*$`)
+	rePinStore  = regexp.MustCompile(`^#\s*PinStore:\s*(\S+)\s*$`)
+)
+
+// ParseSettings reads ssh-menu settings from a reader.
+func ParseSettings(r io.Reader) Settings {
+	var s Settings
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := reView.FindStringSubmatch(line); m != nil {
+			s.Views = append(s.Views, View{Name: m[1], Query: strings.TrimSpace(m[2])})
+		} else if m := reKeyPreset.FindStringSubmatch(line); m != nil {
+			s.Keys.Preset = strings.ToLower(m[1])
+		} else if m := reKey.FindStringSubmatch(line); m != nil {
+			if s.Keys.Bindings == nil {
+				s.Keys.Bindings = make(map[string][]string)
+			}
+			action := strings.ToLower(m[1])
+			s.Keys.Bindings[action] = append(s.Keys.Bindings[action], splitKeys(m[2])...)
+		} else if m := reMatchMode.FindStringSubmatch(line); m != nil {
+			s.MatchMode = strings.ToLower(m[1])
+		} else if m := reStable.FindStringSubmatch(line); m != nil {
+			s.StableNumbers = parseBool(m[1])
+		} else if m := rePinStore.FindStringSubmatch(line); m != nil {
+			s.PinStore = strings.ToLower(m[1])
+		} else if m := reColumns.FindStringSubmatch(line); m != nil {
+			s.Columns = nil
+			for _, c := range strings.Split(m[1], ",") {
+				if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+					s.Columns = append(s.Columns, c)
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// splitKeys parses a comma-separated list of key sequences.
+// A sequence of several keys is written with spaces, e.g. "g g".
+func splitKeys(s string) []string {
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.Join(strings.Fields(k), " "); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// parseBool reads a yes/no setting value.
+func parseBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "yes", "true", "on", "1":
+		return true
+	}
+	return false
+}
+
+// ReadSettings reads settings from the file at path.
+// A missing or unreadable file yields empty settings.
+func ReadSettings(path string) Settings {
+	f, err := os.Open(path)
+	if err != nil {
+		return Settings{}
+	}
+	defer f.Close()
+	return ParseSettings(f)
+}
+
+// FindView returns the saved view with the given name, ignoring case.
+func (s Settings) FindView(name string) (View, bool) {
+	for _, v := range s.Views {
+		if strings.EqualFold(v.Name, name) {
+			return v, true
+		}
+	}
+	return View{}, false
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSettings_Views(t *testing.T) {
+	input := `# View: Prod DBs = env:prod role:db
+# View: Pinned = is:pinned
+# ColorAccent: #ff0000
+`
+	s := ParseSettings(strings.NewReader(input))
+	if len(s.Views) != 2 {
+		t.Fatalf("expected 2 views, got %d: %v", len(s.Views), s.Views)
+	}
+	if s.Views[0].Name != "Prod DBs" || s.Views[0].Query != "env:prod role:db" {
+		t.Errorf("unexpected first view: %+v", s.Views[0])
+	}
+	if s.Views[1].Name != "Pinned" || s.Views[1].Query != "is:pinned" {
+		t.Errorf("unexpected second view: %+v", s.Views[1])
+	}
+}
+
+func TestSettings_FindView(t *testing.T) {
+	s := Settings{Views: []View{{Name: "Prod DBs", Query: "env:prod"}}}
+	v, ok := s.FindView("prod dbs")
+	if !ok || v.Query != "env:prod" {
+		t.Errorf("expected case-insensitive lookup to find view, got %+v (%v)", v, ok)
+	}
+	if _, ok := s.FindView("missing"); ok {
+		t.Error("expected missing view not to be found")
+	}
+}
+
+func TestParseSettings_Keys(t *testing.T) {
+	input := `# KeyPreset: Vim
+# Key: pin = P, ctrl+t
+# Key: top = g  g
+`
+	s := ParseSettings(strings.NewReader(input))
+	if s.Keys.Preset != "vim" {
+		t.Errorf("expected preset vim, got '%s'", s.Keys.Preset)
+	}
+	pin := s.Keys.Bindings["pin"]
+	if len(pin) != 2 || pin[0] != "P" || pin[1] != "ctrl+t" {
+		t.Errorf("expected pin bound to [P ctrl+t], got %v", pin)
+	}
+	top := s.Keys.Bindings["top"]
+	if len(top) != 1 || top[0] != "g g" {
+		t.Errorf("expected top bound to [g g], got %v", top)
+	}
+}
+
+func TestParseSettings_Columns(t *testing.T) {
+	s := ParseSettings(strings.NewReader("# Columns: Alias, userhost , last,\n"))
+	want := []string{"alias", "userhost", "last"}
+	if len(s.Columns) != len(want) {
+		t.Fatalf("expected %v, got %v", want, s.Columns)
+	}
+	for i := range want {
+		if s.Columns[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, s.Columns)
+		}
+	}
+}
+
+func TestParseSettings_MatchMode(t *testing.T) {
+	s := ParseSettings(strings.NewReader("# MatchMode: Exact\n"))
+	if s.MatchMode != "exact" {
+		t.Errorf("expected exact, got '%s'", s.MatchMode)
+	}
+}
+
+func TestParseSettings_StableNumbers(t *testing.T) {
+	if s := ParseSettings(strings.NewReader("# StableNumbers: yes\n")); !s.StableNumbers {
+		t.Error("expected StableNumbers to be enabled")
+	}
+	if s := ParseSettings(strings.NewReader("# StableNumbers: off\n")); s.StableNumbers {
+		t.Error("expected StableNumbers to be disabled")
+	}
+}
+
+func TestParseSettings_PinStore(t *testing.T) {
+	s := ParseSettings(strings.NewReader("# PinStore: State\n"))
+	if s.PinStore != PinStoreState {
+		t.Errorf("expected state, got '%s'", s.PinStore)
+	}
+}
@@ -0,0 +1,59 @@
+// Package editor opens files in the user's editor.
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lineArgEditors take the line to open at as a "+N" argument.
+var lineArgEditors = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "view": true, "gvim": true,
+	"nano": true, "pico": true, "emacs": true, "emacsclient": true,
+	"micro": true, "kak": true, "joe": true, "jed": true, "ne": true, "mg": true,
+}
+
+// colonLineEditors take the line to open at as a "file:N" argument.
+var colonLineEditors = map[string]bool{
+	"subl": true, "hx": true, "helix": true, "zed": true,
+}
+
+// Command returns the command opening file at line in $VISUAL or $EDITOR,
+// falling back to vi. A line of 0 opens the file at the top.
+func Command(file string, line int) *exec.Cmd {
+	return command(fromEnv(), file, line)
+}
+
+// fromEnv returns the user's editor command, split into words.
+func fromEnv() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if words := strings.Fields(os.Getenv(env)); len(words) > 0 {
+			return words
+		}
+	}
+	return []string{"vi"}
+}
+
+// command builds the command opening file at line in editor, using the
+// line syntax the editor understands. Editors not known to take a line
+// just open the file.
+func command(editor []string, file string, line int) *exec.Cmd {
+	args := append([]string{}, editor[1:]...)
+	name := filepath.Base(editor[0])
+	switch {
+	case line <= 0:
+		args = append(args, file)
+	case lineArgEditors[name]:
+		args = append(args, fmt.Sprintf("+%d", line), file)
+	case colonLineEditors[name]:
+		args = append(args, fmt.Sprintf("%s:%d", file, line))
+	case name == "code" || name == "codium" || name == "code-insiders":
+		args = append(args, "--goto", fmt.Sprintf("%s:%d", file, line))
+	default:
+		args = append(args, file)
+	}
+	return exec.Command(editor[0], args...)
+}
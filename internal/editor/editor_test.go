@@ -0,0 +1,44 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		editor []string
+		line   int
+		want   []string
+	}{
+		{[]string{"vim"}, 12, []string{"vim", "+12", "config"}},
+		{[]string{"/usr/bin/nano"}, 3, []string{"/usr/bin/nano", "+3", "config"}},
+		{[]string{"emacsclient", "-t"}, 7, []string{"emacsclient", "-t", "+7", "config"}},
+		{[]string{"hx"}, 5, []string{"hx", "config:5"}},
+		{[]string{"code", "--wait"}, 9, []string{"code", "--wait", "--goto", "config:9"}},
+		{[]string{"ed"}, 4, []string{"ed", "config"}},
+		{[]string{"vim"}, 0, []string{"vim", "config"}},
+	}
+	for _, tt := range tests {
+		if got := command(tt.editor, "config", tt.line).Args; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("command(%q, %d) = %q, want %q", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nvim -u NONE")
+	if got := fromEnv(); !reflect.DeepEqual(got, []string{"nvim", "-u", "NONE"}) {
+		t.Errorf("expected $EDITOR, got %q", got)
+	}
+	t.Setenv("VISUAL", "code --wait")
+	if got := fromEnv(); got[0] != "code" {
+		t.Errorf("expected $VISUAL to win, got %q", got)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	if got := fromEnv(); !reflect.DeepEqual(got, []string{"vi"}) {
+		t.Errorf("expected vi fallback, got %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package host
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Output formats for a selected host, as used by --print and the copy
+// actions.
+const (
+	FormatAlias    = "alias"
+	FormatCommand  = "command"
+	FormatHostName = "hostname"
+	FormatIP       = "ip"
+	FormatUserHost = "user-host"
+)
+
+// Formats lists the output formats in the order shown to users.
+var Formats = []string{FormatAlias, FormatCommand, FormatHostName, FormatIP, FormatUserHost}
+
+// SSHArgs returns the arguments ssh-menu passes to ssh to connect to h.
+func SSHArgs(h Host, verbose bool, sshOpts string) []string {
+	var args []string
+	if verbose {
+		args = append(args, "-v")
+	}
+	if sshOpts != "" {
+		args = append(args, strings.Fields(sshOpts)...)
+	}
+	return append(args, h.ShortName)
+}
+
+// Command returns the ssh command line for h, quoted for a POSIX shell.
+func Command(h Host, verbose bool, sshOpts string) string {
+	words := append([]string{"ssh"}, SSHArgs(h, verbose, sshOpts)...)
+	for i, w := range words {
+		words[i] = shellQuote(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// HostName returns the host's HostName, or its alias when it has none.
+func (h Host) HostName() string {
+	if h.LongName != "" {
+		return h.LongName
+	}
+	return h.ShortName
+}
+
+// UserHost returns user@hostname, or just the hostname when no User is set.
+func (h Host) UserHost() string {
+	if h.User == "" {
+		return h.HostName()
+	}
+	return h.User + "@" + h.HostName()
+}
+
+// Format returns h in one of the output formats. It fails for an unknown
+// format and for FormatIP when the host has no "# IP:" annotation.
+func Format(h Host, format string, verbose bool, sshOpts string) (string, error) {
+	switch format {
+	case FormatAlias:
+		return h.ShortName, nil
+	case FormatCommand:
+		return Command(h, verbose, sshOpts), nil
+	case FormatHostName:
+		return h.HostName(), nil
+	case FormatIP:
+		if h.IP == "" {
+			return "", fmt.Errorf("%s has no IP address", h.ShortName)
+		}
+		return h.IP, nil
+	case FormatUserHost:
+		return h.UserHost(), nil
+	}
+	return "", fmt.Errorf("unknown format %q (use %s)", format, strings.Join(Formats, ", "))
+}
+
+// shellQuote quotes s for a POSIX shell if it contains anything but
+// characters that are always safe unquoted.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@=,+%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,59 @@
+package host
+
+import "testing"
+
+func TestCommand(t *testing.T) {
+	h := Host{ShortName: "web-01"}
+	if got := Command(h, false, ""); got != "ssh web-01" {
+		t.Errorf("expected plain command, got %q", got)
+	}
+	got := Command(h, true, "-p 2222 -o LocalCommand=echo$HOME")
+	want := "ssh -v -p 2222 -o 'LocalCommand=echo$HOME' web-01"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":          "''",
+		"web-01":    "web-01",
+		"a b":       "'a b'",
+		"it's":      `'it'\''s'`,
+		"user@host": "user@host",
+		"$HOME":     "'$HOME'",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	h := Host{ShortName: "db", LongName: "db.example.com", User: "admin", IP: "10.0.0.5"}
+	tests := map[string]string{
+		FormatAlias:    "db",
+		FormatCommand:  "ssh db",
+		FormatHostName: "db.example.com",
+		FormatIP:       "10.0.0.5",
+		FormatUserHost: "admin@db.example.com",
+	}
+	for format, want := range tests {
+		got, err := Format(h, format, false, "")
+		if err != nil || got != want {
+			t.Errorf("Format(%s) = %q, %v; want %q", format, got, err, want)
+		}
+	}
+
+	bare := Host{ShortName: "box"}
+	if got, _ := Format(bare, FormatUserHost, false, ""); got != "box" {
+		t.Errorf("expected alias as hostname fallback, got %q", got)
+	}
+	if _, err := Format(bare, FormatIP, false, ""); err == nil {
+		t.Error("expected error for host without IP")
+	}
+	if _, err := Format(h, "json", false, ""); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -1,79 +1,151 @@
 package host
 
 import (
-	"fmt"
-	"sort"
-	"strings"
+	"context"
 	"unicode"
 )
 
+// Fields a query can match, as reported in MatchResult.Field.
+const (
+	FieldNumber      = "number"
+	FieldAlias       = "alias"
+	FieldDescription = "description"
+	FieldHostName    = "hostname"
+	FieldIP          = "ip"
+	FieldGroups      = "groups"
+)
+
+// MatchResult describes how a host matched a query: the weighted score,
+// which field matched best, that field's text, and the rune indices of
+// the matched characters within it.
+type MatchResult struct {
+	Score     int
+	Field     string
+	Text      string
+	Positions []int
+}
+
+// Result pairs a host with how it matched the filter.
+type Result struct {
+	Host  Host
+	Match MatchResult
+}
+
 // Score computes a fuzzy match score for query against text.
 // Returns 0 if no match. Higher scores are better matches.
 func Score(query, text string) int {
-	if query == "" || text == "" {
-		return 0
-	}
+	score, _ := fuzzyScorer(lowerRunes(query))(newFieldText(text), false)
+	return score
+}
+
+// ScorePositions is like Score but also returns the rune indices in text
+// of the matched characters.
+func ScorePositions(query, text string) (int, []int) {
+	return fuzzyScorer(lowerRunes(query))(newFieldText(text), true)
+}
 
-	queryLower := strings.ToLower(query)
-	textLower := strings.ToLower(text)
+// fieldText is a searchable string with its runes and lowercased runes
+// precomputed, so repeated matching does not allocate.
+type fieldText struct {
+	text  string
+	orig  []rune
+	lower []rune
+}
+
+func newFieldText(s string) *fieldText {
+	return &fieldText{text: s, orig: []rune(s), lower: lowerRunes(s)}
+}
 
-	// Check for exact substring — highest score
-	if idx := strings.Index(textLower, queryLower); idx >= 0 {
-		score := 1000
-		if idx == 0 {
-			score += 20
+// scorer matches a field, returning its score and, if record is set, the
+// rune indices of the matched characters.
+type scorer func(f *fieldText, record bool) (int, []int)
+
+func fuzzyScorer(q []rune) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		if len(q) == 0 || len(f.lower) == 0 {
+			return 0, nil
 		}
-		// Check boundary by finding the rune before the match
-		if idx > 0 {
-			textRunes := []rune(text)
-			// Find rune index corresponding to byte index
-			byteCount := 0
-			for ri, r := range textRunes {
-				if byteCount == idx && ri > 0 {
-					if isBoundary(textRunes[ri-1]) {
-						score += 15
-					}
-					break
-				}
-				byteCount += len(string(r))
+
+		// Check for exact substring — highest score
+		if idx := indexRunes(f.lower, q); idx >= 0 {
+			score := 1000
+			if idx == 0 {
+				score += 20
+			} else if isBoundary(f.orig[idx-1]) {
+				score += 15
+			}
+			if !record {
+				return score, nil
 			}
+			return score, runeRange(idx, len(q))
 		}
-		return score
-	}
 
-	// Fuzzy character-by-character matching using runes
-	queryRunes := []rune(queryLower)
-	textRunes := []rune(textLower)
-	origRunes := []rune(text)
-
-	qi := 0
-	score := 0
-	consecutive := 0
-	lastMatchIdx := -1
-
-	for ti := 0; ti < len(textRunes) && qi < len(queryRunes); ti++ {
-		if textRunes[ti] == queryRunes[qi] {
-			score += 10
-			if lastMatchIdx == ti-1 {
-				consecutive++
-				score += consecutive * 5
-			} else {
-				consecutive = 0
-			}
-			if ti == 0 || isBoundary(origRunes[ti-1]) {
-				score += 8
+		// Fuzzy character-by-character matching using runes
+		qi := 0
+		score := 0
+		consecutive := 0
+		lastMatchIdx := -1
+		var positions []int
+
+		for ti := 0; ti < len(f.lower) && qi < len(q); ti++ {
+			if f.lower[ti] == q[qi] {
+				score += 10
+				if lastMatchIdx == ti-1 {
+					consecutive++
+					score += consecutive * 5
+				} else {
+					consecutive = 0
+				}
+				if ti == 0 || isBoundary(f.orig[ti-1]) {
+					score += 8
+				}
+				score += (len(f.lower) - ti)
+				lastMatchIdx = ti
+				if record {
+					positions = append(positions, ti)
+				}
+				qi++
 			}
-			score += (len(textRunes) - ti)
-			lastMatchIdx = ti
-			qi++
 		}
+
+		if qi < len(q) {
+			return 0, nil
+		}
+
+		return score, positions
 	}
+}
 
-	if qi < len(queryRunes) {
-		return 0
+// lowerRunes lowercases s rune by rune so that indices line up with []rune(s).
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
 	}
+	return runes
+}
 
-	return score
+func indexRunes(text, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	first := sub[0]
+	for i := 0; i+len(sub) <= len(text); i++ {
+		if text[i] != first {
+			continue
+		}
+		match := true
+		for j := 1; j < len(sub); j++ {
+			if text[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
 }
 
 func isBoundary(r rune) bool {
@@ -82,71 +154,41 @@ func isBoundary(r rune) bool {
 
 // Match computes a weighted fuzzy match score across all host fields.
 func Match(query string, h Host) int {
-	best := 0
-	fields := []struct {
-		text   string
-		weight int
-	}{
-		{h.ShortName, 5},
-		{h.DescText, 3},
-		{h.LongName, 3},
-		{h.IP, 2},
-		{strings.Join(h.Groups, " "), 2},
-	}
-	for _, f := range fields {
-		if s := Score(query, f.text); s > 0 {
-			weighted := s * f.weight
-			if weighted > best {
-				best = weighted
-			}
-		}
-	}
-	return best
+	return MatchHost(query, h).Score
 }
 
-// FilterHosts filters and sorts hosts by query.
+// MatchHost computes a weighted fuzzy match across all host fields and
+// reports the best-scoring field along with the matched positions.
+func MatchHost(query string, h Host) MatchResult {
+	return matchFields(newEntry(h), fuzzyScorer(lowerRunes(query)))
+}
+
+// FilterHosts filters and sorts hosts by query. The query may combine
+// structured predicates (see ParseQuery) with free text; hosts must satisfy
+// every predicate and then match the text by menu number or fuzzy score.
 func FilterHosts(query string, hosts []Host) []Host {
-	if query == "" {
-		result := make([]Host, len(hosts))
-		copy(result, hosts)
-		return result
-	}
+	return FilterHostsMode(query, hosts, ModeFuzzy)
+}
 
-	isNumeric := true
-	for _, r := range query {
-		if !unicode.IsDigit(r) {
-			isNumeric = false
-			break
-		}
+// FilterHostsMode is like FilterHosts with the free text matched in the given mode.
+func FilterHostsMode(query string, hosts []Host, mode MatchMode) []Host {
+	results := FilterResultsMode(query, hosts, mode)
+	filtered := make([]Host, len(results))
+	for i, r := range results {
+		filtered[i] = r.Host
 	}
+	return filtered
+}
 
-	if isNumeric {
-		var result []Host
-		for _, h := range hosts {
-			menuStr := fmt.Sprintf("%d", h.MenuNumber)
-			if strings.HasPrefix(menuStr, query) {
-				result = append(result, h)
-			}
-		}
-		return result
-	}
+// FilterResults is like FilterHosts but also reports how each host matched.
+func FilterResults(query string, hosts []Host) []Result {
+	return FilterResultsMode(query, hosts, ModeFuzzy)
+}
 
-	type scored struct {
-		host  Host
-		score int
-	}
-	var matches []scored
-	for _, h := range hosts {
-		if s := Match(query, h); s > 0 {
-			matches = append(matches, scored{host: h, score: s})
-		}
-	}
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].score > matches[j].score
-	})
-	result := make([]Host, len(matches))
-	for i, m := range matches {
-		result[i] = m.host
-	}
-	return result
+// FilterResultsMode filters hosts like FilterResults, matching the free
+// text of the query with the given mode. Callers filtering the same hosts
+// repeatedly should build an Index instead.
+func FilterResultsMode(query string, hosts []Host, mode MatchMode) []Result {
+	f, _ := NewIndex(hosts).Filter(context.Background(), query, mode, nil)
+	return f.Results
 }
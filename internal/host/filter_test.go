@@ -85,3 +85,63 @@ func TestFilterHosts_EmptyQueryReturnsAll(t *testing.T) {
 		t.Errorf("expected all hosts returned, got %d", len(result))
 	}
 }
+
+func TestFilterHosts_PredicatesWithText(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "db-prod", MenuNumber: 1, Tags: map[string]string{"env": "prod", "role": "db"}},
+		{ShortName: "web-prod", MenuNumber: 2, Tags: map[string]string{"env": "prod", "role": "web"}},
+		{ShortName: "db-stage", MenuNumber: 3, Tags: map[string]string{"env": "stage", "role": "db"}},
+	}
+	result := FilterHosts("env:prod db", hosts)
+	if len(result) != 1 || result[0].ShortName != "db-prod" {
+		t.Fatalf("expected only db-prod, got %v", result)
+	}
+	result = FilterHosts("role:db", hosts)
+	if len(result) != 2 {
+		t.Errorf("expected 2 db hosts, got %d", len(result))
+	}
+}
+
+func TestScorePositions_Substring(t *testing.T) {
+	s, pos := ScorePositions("prod", "web-prod-01")
+	if s <= 0 {
+		t.Fatalf("expected match, got %d", s)
+	}
+	want := []int{4, 5, 6, 7}
+	if len(pos) != len(want) {
+		t.Fatalf("expected positions %v, got %v", want, pos)
+	}
+	for i := range want {
+		if pos[i] != want[i] {
+			t.Errorf("expected positions %v, got %v", want, pos)
+			break
+		}
+	}
+}
+
+func TestScorePositions_FuzzyMultibyte(t *testing.T) {
+	_, pos := ScorePositions("äc", "ärger-cache")
+	if len(pos) != 2 || pos[0] != 0 || pos[1] != 6 {
+		t.Errorf("expected rune positions [0 6], got %v", pos)
+	}
+}
+
+func TestMatchHost_ReportsField(t *testing.T) {
+	h := Host{ShortName: "web-01", IP: "10.0.1.5", DescText: "frontend"}
+	m := MatchHost("10.0", h)
+	if m.Field != FieldIP || m.Text != "10.0.1.5" {
+		t.Errorf("expected IP field match, got %+v", m)
+	}
+	m = MatchHost("web", h)
+	if m.Field != FieldAlias {
+		t.Errorf("expected alias field match, got %+v", m)
+	}
+}
+
+func TestFilterResults_NumericPositions(t *testing.T) {
+	hosts := []Host{{ShortName: "a", MenuNumber: 12}}
+	results := FilterResults("1", hosts)
+	if len(results) != 1 || results[0].Match.Field != FieldNumber || len(results[0].Match.Positions) != 1 {
+		t.Errorf("expected number match with one position, got %+v", results)
+	}
+}
@@ -5,16 +5,63 @@ import (
 	"sort"
 )
 
+// NumberingOptions controls how AssignMenuNumbers numbers hosts without an
+// explicit menu number.
+type NumberingOptions struct {
+	// Persisted maps Host.Key to a number auto-assigned on an earlier run.
+	// Hosts found here keep that number while it is free, so adding a host
+	// does not shift the numbers of the hosts after it.
+	Persisted map[string]int
+
+	// Strict makes a duplicate explicit menu number an error instead of
+	// a warning.
+	Strict bool
+}
+
+// conflict records a host whose explicit menu number was already taken.
+type conflict struct {
+	index  int
+	number int
+	owner  int
+}
+
 // AssignMenuNumbers validates and assigns menu numbers to hosts.
-// Returns an error if duplicate explicit menu numbers are found.
+// When two hosts claim the same explicit number, the first in file order
+// keeps it and the other is renumbered with a warning.
 func AssignMenuNumbers(hosts []Host) ([]Host, error) {
+	return AssignMenuNumbersWith(hosts, NumberingOptions{})
+}
+
+// AssignMenuNumbersWith is like AssignMenuNumbers with the given options.
+// Hosts given a number here have AutoNumber set.
+func AssignMenuNumbersWith(hosts []Host, opts NumberingOptions) ([]Host, error) {
 	usedNumbers := make(map[int]bool)
-	for _, h := range hosts {
-		if h.MenuNumber != 0 {
-			if usedNumbers[h.MenuNumber] {
+	owners := make(map[int]int)
+	var conflicts []conflict
+	for i, h := range hosts {
+		if h.MenuNumber == 0 {
+			continue
+		}
+		if owner, taken := owners[h.MenuNumber]; taken {
+			if opts.Strict {
 				return nil, fmt.Errorf("duplicate menu number %d found for host %s", h.MenuNumber, h.ShortName)
 			}
-			usedNumbers[h.MenuNumber] = true
+			conflicts = append(conflicts, conflict{index: i, number: h.MenuNumber, owner: owner})
+			hosts[i].MenuNumber = 0
+			continue
+		}
+		owners[h.MenuNumber] = i
+		usedNumbers[h.MenuNumber] = true
+	}
+
+	for i, h := range hosts {
+		if h.MenuNumber != 0 {
+			continue
+		}
+		if n := opts.Persisted[h.Key()]; n > 0 && !usedNumbers[n] {
+			hosts[i].MenuNumber = n
+			hosts[i].AutoNumber = true
+			usedNumbers[n] = true
 		}
 	}
 
@@ -25,10 +72,18 @@ func AssignMenuNumbers(hosts []Host) ([]Host, error) {
 				nextAvailable++
 			}
 			hosts[i].MenuNumber = nextAvailable
+			hosts[i].AutoNumber = true
 			usedNumbers[nextAvailable] = true
 		}
 	}
 
+	for _, c := range conflicts {
+		owner, h := hosts[c.owner], hosts[c.index]
+		hosts[c.index].Warnings = append(h.Warnings, h.warning(h.AnnotationLine(AnnotationMenu),
+			"Menu number %d is already used by %s (%s); using %d instead",
+			c.number, owner.ShortName, owner.Location(), h.MenuNumber))
+	}
+
 	sort.Slice(hosts, func(i, j int) bool {
 		return hosts[i].MenuNumber < hosts[j].MenuNumber
 	})
@@ -36,6 +91,18 @@ func AssignMenuNumbers(hosts []Host) ([]Host, error) {
 	return hosts, nil
 }
 
+// AutoNumbers returns the auto-assigned menu numbers of hosts keyed by
+// Host.Key, for passing back as NumberingOptions.Persisted.
+func AutoNumbers(hosts []Host) map[string]int {
+	numbers := make(map[string]int)
+	for _, h := range hosts {
+		if h.AutoNumber {
+			numbers[h.Key()] = h.MenuNumber
+		}
+	}
+	return numbers
+}
+
 // GetAllGroups returns a sorted list of all unique groups.
 // "Ungrouped" is placed last if any hosts have no groups.
 func GetAllGroups(hosts []Host) []string {
@@ -73,22 +140,90 @@ func GetAllGroups(hosts []Host) []string {
 	return groups
 }
 
+// OrderGroups returns groups with those named in order first, in that
+// order, followed by the others in their original order.
+func OrderGroups(groups, order []string) []string {
+	rank := make(map[string]int, len(order))
+	for i, g := range order {
+		rank[g] = i + 1
+	}
+	sorted := make([]string, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return orderLess(rank[sorted[i]], rank[sorted[j]])
+	})
+	return sorted
+}
+
+// ApplyOrder sets Host.Order from the keys of hosts in the order the user
+// arranged them. Keys include the source file, so hosts sharing an alias
+// keep their own places. Hosts not in order are left unordered.
+func ApplyOrder(hosts []Host, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		if _, ok := rank[key]; !ok {
+			rank[key] = i + 1
+		}
+	}
+	for i := range hosts {
+		hosts[i].Order = rank[hosts[i].Key()]
+	}
+}
+
+// Ordering returns the keys of hosts as SortWithPins orders them, for
+// saving after the user rearranges them.
+func Ordering(hosts []Host) []string {
+	var order []string
+	for _, h := range SortWithPins(hosts) {
+		order = append(order, h.Key())
+	}
+	return order
+}
+
 // SortWithPins returns a copy of hosts sorted with pinned hosts first,
-// then by menu number within each group.
+// then in the order arranged by the user, then by menu number.
 func SortWithPins(hosts []Host) []Host {
 	sorted := make([]Host, len(hosts))
 	copy(sorted, hosts)
 
 	sort.SliceStable(sorted, func(i, j int) bool {
-		if sorted[i].Pinned != sorted[j].Pinned {
-			return sorted[i].Pinned
-		}
-		return sorted[i].MenuNumber < sorted[j].MenuNumber
+		return pinnedLess(sorted[i], sorted[j])
 	})
 
 	return sorted
 }
 
+// SortResultsWithPins orders filter results like SortWithPins.
+func SortResultsWithPins(results []Result) []Result {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return pinnedLess(sorted[i].Host, sorted[j].Host)
+	})
+
+	return sorted
+}
+
+func pinnedLess(a, b Host) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	if a.Order != b.Order {
+		return orderLess(a.Order, b.Order)
+	}
+	return a.MenuNumber < b.MenuNumber
+}
+
+// orderLess compares positions arranged by the user, where 0 means none.
+// Arranged items come before the rest.
+func orderLess(a, b int) bool {
+	if a == 0 || b == 0 {
+		return b == 0 && a != 0
+	}
+	return a < b
+}
+
 // HostsForGroup returns hosts belonging to a specific group.
 func HostsForGroup(hosts []Host, groupName string) []Host {
 	var result []Host
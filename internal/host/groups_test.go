@@ -1,6 +1,8 @@
 package host
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -46,14 +48,98 @@ func TestAssignMenuNumbers_AutoAssign(t *testing.T) {
 	}
 }
 
-func TestAssignMenuNumbers_DuplicateError(t *testing.T) {
+func TestAssignMenuNumbers_DuplicateWarning(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "a", DescText: "desc", MenuNumber: 1, SourceFile: "config", Line: 3},
+		{ShortName: "b", DescText: "desc", MenuNumber: 2, SourceFile: "config", Line: 6},
+		{ShortName: "c", DescText: "desc", MenuNumber: 1, SourceFile: "config.d/team", Line: 9,
+			Annotations: []Annotation{{Kind: AnnotationMenu, Value: "desc", Line: 8}}},
+	}
+	result, err := AssignMenuNumbers(hosts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result[0].ShortName != "a" || result[0].MenuNumber != 1 || len(result[0].Warnings) != 0 {
+		t.Errorf("expected first host in file order to keep 1 without warnings, got %+v", result[0])
+	}
+	c := result[2]
+	if c.ShortName != "c" || c.MenuNumber != 3 {
+		t.Fatalf("expected c to be reassigned to 3, got %s=%d", c.ShortName, c.MenuNumber)
+	}
+	if !c.AutoNumber {
+		t.Error("expected reassigned host to be marked auto-numbered")
+	}
+	if len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0].Message, "already used by a (config:3)") {
+		t.Errorf("expected a conflict warning naming a, got %v", c.Warnings)
+	}
+	if loc := c.Warnings[0].Location(); loc != "config.d/team:8" {
+		t.Errorf("expected warning at c's Menu line config.d/team:8, got %q", loc)
+	}
+}
+
+func TestAssignMenuNumbers_DuplicateStrict(t *testing.T) {
 	hosts := []Host{
 		{ShortName: "a", DescText: "desc", MenuNumber: 1},
 		{ShortName: "b", DescText: "desc", MenuNumber: 1},
 	}
-	_, err := AssignMenuNumbers(hosts)
+	_, err := AssignMenuNumbersWith(hosts, NumberingOptions{Strict: true})
 	if err == nil {
-		t.Fatal("expected error for duplicate menu numbers")
+		t.Fatal("expected error for duplicate menu numbers in strict mode")
+	}
+}
+
+func TestAssignMenuNumbers_PersistedNumbersSurviveInsertion(t *testing.T) {
+	first, _ := AssignMenuNumbers([]Host{
+		{ShortName: "b", DescText: "desc", SourceFile: "cfg"},
+		{ShortName: "c", DescText: "desc", SourceFile: "cfg"},
+	})
+	persisted := AutoNumbers(first)
+
+	// A host inserted at the top of the file takes the next free number
+	// instead of shifting everyone else.
+	result, err := AssignMenuNumbersWith([]Host{
+		{ShortName: "a", DescText: "desc", SourceFile: "cfg"},
+		{ShortName: "b", DescText: "desc", SourceFile: "cfg"},
+		{ShortName: "c", DescText: "desc", SourceFile: "cfg"},
+	}, NumberingOptions{Persisted: persisted})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	numbers := map[string]int{}
+	for _, h := range result {
+		numbers[h.ShortName] = h.MenuNumber
+		if !h.AutoNumber {
+			t.Errorf("expected %s to be marked auto-numbered", h.ShortName)
+		}
+	}
+	if numbers["b"] != 1 || numbers["c"] != 2 || numbers["a"] != 3 {
+		t.Errorf("expected b=1 c=2 a=3, got %v", numbers)
+	}
+}
+
+func TestAssignMenuNumbers_PersistedNumberTakenByExplicit(t *testing.T) {
+	result, err := AssignMenuNumbersWith([]Host{
+		{ShortName: "a", DescText: "desc", MenuNumber: 1, SourceFile: "cfg"},
+		{ShortName: "b", DescText: "desc", SourceFile: "cfg"},
+	}, NumberingOptions{Persisted: map[string]int{"b@cfg": 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result[0].AutoNumber {
+		t.Error("explicitly numbered host should not be marked auto-numbered")
+	}
+	if result[1].ShortName != "b" || result[1].MenuNumber != 2 {
+		t.Errorf("expected b to be renumbered to 2, got %s=%d", result[1].ShortName, result[1].MenuNumber)
+	}
+}
+
+func TestAutoNumbers(t *testing.T) {
+	numbers := AutoNumbers([]Host{
+		{ShortName: "a", SourceFile: "cfg", MenuNumber: 1},
+		{ShortName: "b", SourceFile: "cfg", MenuNumber: 2, AutoNumber: true},
+	})
+	if len(numbers) != 1 || numbers["b@cfg"] != 2 {
+		t.Errorf("expected only b@cfg=2, got %v", numbers)
 	}
 }
 
@@ -95,3 +181,41 @@ func TestSortWithPins_PinnedFirst(t *testing.T) {
 		t.Errorf("expected c third, got %s", result[2].ShortName)
 	}
 }
+
+func TestSortWithPins_UserOrder(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "a", MenuNumber: 1, SourceFile: "f"},
+		{ShortName: "b", MenuNumber: 2, SourceFile: "f", Pinned: true},
+		{ShortName: "c", MenuNumber: 3, SourceFile: "f"},
+		{ShortName: "d", MenuNumber: 4, SourceFile: "f"},
+	}
+	ApplyOrder(hosts, []string{"b@f", "c@f", "gone@f", "a@f"})
+	got := Ordering(hosts)
+	want := []string{"b@f", "c@f", "a@f", "d@f"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyOrder_DuplicateAliases(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "web", MenuNumber: 1, SourceFile: "a"},
+		{ShortName: "db", MenuNumber: 2, SourceFile: "a"},
+		{ShortName: "web", MenuNumber: 3, SourceFile: "b"},
+	}
+	ApplyOrder(hosts, []string{"web@b", "db@a", "web@a"})
+	got := Ordering(hosts)
+	want := []string{"web@b", "db@a", "web@a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestOrderGroups(t *testing.T) {
+	groups := []string{"Alpha", "Beta", "Zebra", "Ungrouped"}
+	got := OrderGroups(groups, []string{"Zebra", "Gone", "Alpha"})
+	want := []string{"Zebra", "Alpha", "Beta", "Ungrouped"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
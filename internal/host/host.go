@@ -2,29 +2,108 @@ package host
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
-// Warning represents a validation warning for a host.
+// Warning represents a validation warning for a host. File and Line
+// locate the config line the warning is about, when known.
 type Warning struct {
 	Level   string // "warn"
 	Message string
+	File    string
+	Line    int
+}
+
+// Location returns where the warning applies as file:line, or "" if unknown.
+func (w Warning) Location() string {
+	return location(w.File, w.Line)
+}
+
+// Annotation kinds.
+const (
+	AnnotationMenu   = "Menu"
+	AnnotationGroup  = "Group"
+	AnnotationIP     = "IP"
+	AnnotationTag    = "Tag"
+	AnnotationPinned = "Pinned"
+)
+
+// Annotation is the position of an ssh-menu comment such as "# Menu:" or
+// "# Group:" that describes a host. Kind is the comment's keyword.
+type Annotation struct {
+	Kind  string
+	Value string
+	Line  int
 }
 
 // Host represents an SSH config host entry.
 type Host struct {
-	ShortName    string
-	LongName     string
-	User         string
-	Port         string
-	IP           string
-	IdentityFile string
-	DescText     string
-	MenuNumber   int
-	Groups       []string
-	Pinned       bool
-	SourceFile   string
-	Warnings     []Warning
+	ShortName     string
+	LongName      string
+	User          string
+	Port          string
+	IP            string
+	IdentityFile  string
+	DescText      string
+	MenuNumber    int
+	AutoNumber    bool
+	Groups        []string
+	Tags          map[string]string
+	Pinned        bool
+	Order         int // position arranged by the user, from 1; 0 if none
+	SourceFile    string
+	Line          int // line of the Host directive in SourceFile
+	EndLine       int // last line of the Host block
+	Annotations   []Annotation
+	Warnings      []Warning
+	Reachable     Reachability
+	LastConnected time.Time
+}
+
+// Key returns an identifier for the host that is unique across config files.
+func (h Host) Key() string {
+	return h.ShortName + "@" + h.SourceFile
+}
+
+// Location returns where the host is defined as file:line.
+func (h Host) Location() string {
+	return location(h.SourceFile, h.Line)
+}
+
+// AnnotationLine returns the line of the host's first annotation of the
+// given kind, or the Host line if there is none.
+func (h Host) AnnotationLine(kind string) int {
+	for _, a := range h.Annotations {
+		if a.Kind == kind {
+			return a.Line
+		}
+	}
+	return h.Line
+}
+
+// PinnedInConfig reports whether the host has a # Pinned comment. Pinned
+// also reflects pins kept in the state file.
+func (h Host) PinnedInConfig() bool {
+	for _, a := range h.Annotations {
+		if a.Kind == AnnotationPinned {
+			return true
+		}
+	}
+	return false
+}
+
+// warning returns a warning located at the given line of the host's file.
+func (h Host) warning(line int, format string, args ...any) Warning {
+	return Warning{Level: "warn", Message: fmt.Sprintf(format, args...), File: h.SourceFile, Line: line}
+}
+
+func location(file string, line int) string {
+	if file == "" || line == 0 {
+		return file
+	}
+	return fmt.Sprintf("%s:%d", file, line)
 }
 
 // Title returns a formatted string for displaying the host in the list.
@@ -38,6 +117,24 @@ func (h Host) Title() string {
 
 // FilterValue returns a string used for filtering.
 func (h Host) FilterValue() string {
-	return fmt.Sprintf("%d %s %s %s %s %s",
-		h.MenuNumber, h.ShortName, h.DescText, h.LongName, h.IP, strings.Join(h.Groups, " "))
+	return fmt.Sprintf("%d %s %s %s %s %s %s",
+		h.MenuNumber, h.ShortName, h.DescText, h.LongName, h.IP, strings.Join(h.Groups, " "), h.TagString())
+}
+
+// TagString returns the host's tags as sorted, space-separated key=value pairs.
+func (h Host) TagString() string {
+	keys := make([]string, 0, len(h.Tags))
+	for k := range h.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		if h.Tags[k] == "" {
+			parts[i] = k
+		} else {
+			parts[i] = k + "=" + h.Tags[k]
+		}
+	}
+	return strings.Join(parts, " ")
 }
@@ -0,0 +1,200 @@
+package host
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// cancelCheckInterval is how many hosts are scanned between checks for a
+// cancelled filter.
+const cancelCheckInterval = 1024
+
+// indexedField is one searchable host field with its match weight.
+type indexedField struct {
+	name   string
+	weight int
+	*fieldText
+}
+
+// entry holds the precomputed searchable fields of one host.
+type entry struct {
+	fields []indexedField
+	number string
+}
+
+func newEntry(h Host) entry {
+	return entry{
+		fields: []indexedField{
+			{FieldAlias, 5, newFieldText(h.ShortName)},
+			{FieldDescription, 3, newFieldText(h.DescText)},
+			{FieldHostName, 3, newFieldText(h.LongName)},
+			{FieldIP, 2, newFieldText(h.IP)},
+			{FieldGroups, 2, newFieldText(strings.Join(h.Groups, " "))},
+		},
+		number: strconv.Itoa(h.MenuNumber),
+	}
+}
+
+// matchFields scores every searchable field and returns the best weighted
+// result. Positions are only computed for the winning field.
+func matchFields(e entry, score scorer) MatchResult {
+	best, bestScore := -1, 0
+	for i, f := range e.fields {
+		if len(f.orig) == 0 {
+			continue
+		}
+		if s, _ := score(f.fieldText, false); s > 0 && s*f.weight > bestScore {
+			best, bestScore = i, s*f.weight
+		}
+	}
+	if best < 0 {
+		return MatchResult{}
+	}
+	f := e.fields[best]
+	_, positions := score(f.fieldText, true)
+	return MatchResult{Score: bestScore, Field: f.name, Text: f.text, Positions: positions}
+}
+
+// Index holds hosts with their searchable fields precomputed, for fast
+// repeated filtering of a large inventory.
+type Index struct {
+	hosts   []Host
+	entries []entry
+	// tagKeys holds the lower-cased tag keys of the hosts, which queries
+	// may use as predicate keys.
+	tagKeys map[string]bool
+}
+
+// NewIndex builds an index over hosts.
+func NewIndex(hosts []Host) *Index {
+	ix := &Index{hosts: hosts, entries: make([]entry, len(hosts)), tagKeys: make(map[string]bool)}
+	for i, h := range hosts {
+		ix.entries[i] = newEntry(h)
+		for k := range h.Tags {
+			ix.tagKeys[strings.ToLower(k)] = true
+		}
+	}
+	return ix
+}
+
+// Len returns the number of indexed hosts.
+func (ix *Index) Len() int {
+	return len(ix.hosts)
+}
+
+// Filtered is the outcome of Index.Filter. Passing it back to Filter as
+// prev lets a query that extends this one reuse its matches.
+type Filtered struct {
+	Results []Result
+
+	index   *Index
+	query   Query
+	mode    MatchMode
+	matched []int
+}
+
+// Filter matches the index against query in the given mode. If prev came
+// from the same index and the new query only narrows it — the same
+// predicates with more free text appended, in a mode where that can only
+// remove matches — just prev's matches are rescanned. Filter returns
+// ctx.Err() if ctx is cancelled before it finishes.
+func (ix *Index) Filter(ctx context.Context, query string, mode MatchMode, prev *Filtered) (*Filtered, error) {
+	q := ParseQuery(query, ix.tagKeys)
+	out := &Filtered{index: ix, query: q, mode: mode}
+
+	var candidates []int
+	if prev != nil && prev.canRefine(ix, q, mode) {
+		candidates = prev.matched
+	} else {
+		candidates = make([]int, len(ix.hosts))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	match := ix.textMatcher(q.Text, mode)
+	for n, i := range candidates {
+		if n%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if len(q.Predicates) > 0 && !q.Matches(ix.hosts[i]) {
+			continue
+		}
+		m, ok := match(i)
+		if !ok {
+			continue
+		}
+		out.matched = append(out.matched, i)
+		out.Results = append(out.Results, Result{Host: ix.hosts[i], Match: m})
+	}
+
+	if q.Text != "" && !isNumericQuery(q.Text, mode) {
+		sort.SliceStable(out.Results, func(i, j int) bool {
+			return out.Results[i].Match.Score > out.Results[j].Match.Score
+		})
+	}
+	return out, nil
+}
+
+func (f *Filtered) canRefine(ix *Index, q Query, mode MatchMode) bool {
+	if f.index != ix || f.mode != mode {
+		return false
+	}
+	if mode != ModeFuzzy && mode != ModeExact && mode != ModePrefix {
+		return false
+	}
+	if len(f.query.Predicates) != len(q.Predicates) {
+		return false
+	}
+	for i := range q.Predicates {
+		if f.query.Predicates[i] != q.Predicates[i] {
+			return false
+		}
+	}
+	return strings.HasPrefix(q.Text, f.query.Text) &&
+		isNumericQuery(q.Text, mode) == isNumericQuery(f.query.Text, mode)
+}
+
+// textMatcher returns a function matching the free text against the
+// indexed host at position i.
+func (ix *Index) textMatcher(text string, mode MatchMode) func(i int) (MatchResult, bool) {
+	if text == "" {
+		return func(int) (MatchResult, bool) { return MatchResult{}, true }
+	}
+
+	if isNumericQuery(text, mode) {
+		positions := runeRange(0, len(text))
+		return func(i int) (MatchResult, bool) {
+			number := ix.entries[i].number
+			if !strings.HasPrefix(number, text) {
+				return MatchResult{}, false
+			}
+			return MatchResult{Score: 1, Field: FieldNumber, Text: number, Positions: positions}, true
+		}
+	}
+
+	match := newMatcher(text, mode)
+	return func(i int) (MatchResult, bool) {
+		m := match(ix.entries[i])
+		return m, m.Score > 0
+	}
+}
+
+// isNumericQuery reports whether text selects hosts by menu number.
+// Digits are a pattern in regex mode, so it never applies there.
+func isNumericQuery(text string, mode MatchMode) bool {
+	if text == "" || mode == ModeRegex {
+		return false
+	}
+	for _, r := range text {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func generateHosts(n int) []Host {
+	envs := []string{"prod", "staging", "dev", "qa"}
+	roles := []string{"web", "db", "cache", "queue", "worker"}
+	hosts := make([]Host, n)
+	for i := range hosts {
+		env, role := envs[i%len(envs)], roles[i%len(roles)]
+		hosts[i] = Host{
+			ShortName:  fmt.Sprintf("%s-%s-%05d", role, env, i),
+			LongName:   fmt.Sprintf("%s%d.%s.example.com", role, i, env),
+			IP:         fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255),
+			DescText:   fmt.Sprintf("%s %s server", env, role),
+			Groups:     []string{env, role},
+			MenuNumber: i + 1,
+		}
+	}
+	return hosts
+}
+
+func resultNames(results []Result) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Host.ShortName
+	}
+	return names
+}
+
+func TestIndexFilter_RefineMatchesFullScan(t *testing.T) {
+	ix := NewIndex(generateHosts(500))
+	ctx := context.Background()
+
+	var prev *Filtered
+	for _, q := range []string{"w", "we", "web", "web-p", "web-pr"} {
+		got, err := ix.Filter(ctx, q, ModeFuzzy, prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := ix.Filter(ctx, q, ModeFuzzy, nil)
+		if fmt.Sprint(resultNames(got.Results)) != fmt.Sprint(resultNames(want.Results)) {
+			t.Fatalf("refined results for %q differ from a full scan", q)
+		}
+		prev = got
+	}
+}
+
+func TestIndexFilter_NoRefineWhenQueryShrinks(t *testing.T) {
+	ix := NewIndex(generateHosts(100))
+	ctx := context.Background()
+
+	narrow, _ := ix.Filter(ctx, "db-prod", ModeFuzzy, nil)
+	wide, _ := ix.Filter(ctx, "db", ModeFuzzy, narrow)
+	full, _ := ix.Filter(ctx, "db", ModeFuzzy, nil)
+	if len(wide.Results) != len(full.Results) {
+		t.Errorf("got %d results after deleting text, want %d", len(wide.Results), len(full.Results))
+	}
+}
+
+func TestIndexFilter_Cancelled(t *testing.T) {
+	ix := NewIndex(generateHosts(100))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ix.Filter(ctx, "web", ModeFuzzy, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func benchmarkFilter(b *testing.B, n int) {
+	ix := NewIndex(generateHosts(n))
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ix.Filter(ctx, "webprd", ModeFuzzy, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilter1k(b *testing.B)   { benchmarkFilter(b, 1_000) }
+func BenchmarkFilter10k(b *testing.B)  { benchmarkFilter(b, 10_000) }
+func BenchmarkFilter100k(b *testing.B) { benchmarkFilter(b, 100_000) }
+
+// benchmarkTyping filters as if the query were typed one key at a time,
+// refining each result from the last.
+func benchmarkTyping(b *testing.B, n int) {
+	ix := NewIndex(generateHosts(n))
+	ctx := context.Background()
+	query := "web-prod-0"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var prev *Filtered
+		for j := 1; j <= len(query); j++ {
+			f, err := ix.Filter(ctx, query[:j], ModeFuzzy, prev)
+			if err != nil {
+				b.Fatal(err)
+			}
+			prev = f
+		}
+	}
+}
+
+func BenchmarkTyping1k(b *testing.B)   { benchmarkTyping(b, 1_000) }
+func BenchmarkTyping10k(b *testing.B)  { benchmarkTyping(b, 10_000) }
+func BenchmarkTyping100k(b *testing.B) { benchmarkTyping(b, 100_000) }
+
+func BenchmarkNewIndex100k(b *testing.B) {
+	hosts := generateHosts(100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewIndex(hosts)
+	}
+}
@@ -0,0 +1,261 @@
+package host
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MatchMode selects how the free text of a query is matched.
+type MatchMode int
+
+const (
+	ModeFuzzy MatchMode = iota
+	ModeExact
+	ModePrefix
+	ModeRegex
+	ModeExtended
+)
+
+var modeNames = []string{"fuzzy", "exact", "prefix", "regex", "extended"}
+
+// String returns the mode's name as accepted by ParseMatchMode.
+func (m MatchMode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return fmt.Sprintf("MatchMode(%d)", int(m))
+}
+
+// Next returns the mode after m, wrapping around.
+func (m MatchMode) Next() MatchMode {
+	return MatchMode((int(m) + 1) % len(modeNames))
+}
+
+// ParseMatchMode returns the mode with the given name.
+func ParseMatchMode(s string) (MatchMode, error) {
+	for i, name := range modeNames {
+		if strings.EqualFold(s, name) {
+			return MatchMode(i), nil
+		}
+	}
+	return ModeFuzzy, fmt.Errorf("unknown match mode '%s' (want one of %s)", s, strings.Join(modeNames, ", "))
+}
+
+// newMatcher returns a function matching text against an indexed host in
+// the given mode.
+func newMatcher(query string, mode MatchMode) func(entry) MatchResult {
+	q := lowerRunes(query)
+	switch mode {
+	case ModeExact:
+		return fieldMatcher(substringScorer(q))
+	case ModePrefix:
+		return fieldMatcher(prefixScorer(q))
+	case ModeRegex:
+		re, err := regexp.Compile("(?i)" + query)
+		if err != nil {
+			// Half-typed patterns match literally rather than hiding everything.
+			re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+		}
+		return fieldMatcher(regexScorer(re))
+	case ModeExtended:
+		return extendedMatcher(parseExtended(query))
+	}
+	return fieldMatcher(fuzzyScorer(q))
+}
+
+func fieldMatcher(score scorer) func(entry) MatchResult {
+	return func(e entry) MatchResult { return matchFields(e, score) }
+}
+
+// substringScorer matches q as a case-insensitive substring.
+func substringScorer(q []rune) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		idx := indexRunes(f.lower, q)
+		if idx < 0 {
+			return 0, nil
+		}
+		score := 1000
+		if idx == 0 {
+			score += 20
+		} else if isBoundary(f.orig[idx-1]) {
+			score += 15
+		}
+		return score, recordRange(record, idx, len(q))
+	}
+}
+
+// prefixScorer matches fields that start with q, ignoring case.
+func prefixScorer(q []rune) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		if len(q) == 0 || len(q) > len(f.lower) || indexRunes(f.lower[:len(q)], q) != 0 {
+			return 0, nil
+		}
+		return 1000 + len(q)*10 - len(f.lower), recordRange(record, 0, len(q))
+	}
+}
+
+// suffixScorer matches fields that end with q, ignoring case.
+func suffixScorer(q []rune) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		start := len(f.lower) - len(q)
+		if len(q) == 0 || start < 0 || indexRunes(f.lower[start:], q) != 0 {
+			return 0, nil
+		}
+		return 1000 + len(q)*10 - len(f.lower), recordRange(record, start, len(q))
+	}
+}
+
+// wholeScorer matches fields equal to q, ignoring case.
+func wholeScorer(q []rune) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		if len(q) == 0 || len(q) != len(f.lower) || indexRunes(f.lower, q) != 0 {
+			return 0, nil
+		}
+		return 2000, recordRange(record, 0, len(q))
+	}
+}
+
+func regexScorer(re *regexp.Regexp) scorer {
+	return func(f *fieldText, record bool) (int, []int) {
+		loc := re.FindStringIndex(f.text)
+		if loc == nil || loc[0] == loc[1] {
+			return 0, nil
+		}
+		start := utf8.RuneCountInString(f.text[:loc[0]])
+		n := utf8.RuneCountInString(f.text[loc[0]:loc[1]])
+		score := 1000 + n*10
+		if start == 0 {
+			score += 20
+		}
+		return score, recordRange(record, start, n)
+	}
+}
+
+func recordRange(record bool, start, n int) []int {
+	if !record {
+		return nil
+	}
+	return runeRange(start, n)
+}
+
+func runeRange(start, n int) []int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return positions
+}
+
+// extTerm is one term of an fzf-style extended query.
+type extTerm struct {
+	score  scorer
+	negate bool
+}
+
+// parseExtended splits an extended query into AND-ed groups of OR-ed terms.
+// Terms are separated by spaces and "|" joins alternatives:
+//
+//	'exact   substring match
+//	^prefix  match at the start of a field
+//	suffix$  match at the end of a field
+//	!term    exclude hosts matching term
+//	term     fuzzy match
+func parseExtended(query string) [][]extTerm {
+	var groups [][]extTerm
+	var current []extTerm
+	orNext := false
+	for _, tok := range strings.Fields(query) {
+		if tok == "|" {
+			orNext = true
+			continue
+		}
+		term, ok := parseExtTerm(tok)
+		if !ok {
+			continue
+		}
+		if orNext && len(current) > 0 {
+			current = append(current, term)
+		} else {
+			if len(current) > 0 {
+				groups = append(groups, current)
+			}
+			current = []extTerm{term}
+		}
+		orNext = false
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func parseExtTerm(tok string) (extTerm, bool) {
+	var t extTerm
+	if strings.HasPrefix(tok, "!") {
+		t.negate = true
+		tok = tok[1:]
+	}
+	prefix := strings.HasPrefix(tok, "^")
+	suffix := strings.HasSuffix(tok, "$") && len(tok) > 1
+	var text string
+	var newScorer func([]rune) scorer
+	switch {
+	case strings.HasPrefix(tok, "'"):
+		text, newScorer = tok[1:], substringScorer
+	case prefix && suffix:
+		text, newScorer = tok[1:len(tok)-1], wholeScorer
+	case prefix:
+		text, newScorer = tok[1:], prefixScorer
+	case suffix:
+		text, newScorer = tok[:len(tok)-1], suffixScorer
+	case t.negate:
+		// fzf treats a negated plain term as an exact (not fuzzy) match.
+		text, newScorer = tok, substringScorer
+	default:
+		text, newScorer = tok, fuzzyScorer
+	}
+	if text == "" {
+		return t, false
+	}
+	t.score = newScorer(lowerRunes(text))
+	return t, true
+}
+
+func extendedMatcher(groups [][]extTerm) func(entry) MatchResult {
+	return func(e entry) MatchResult {
+		var result MatchResult
+		for _, group := range groups {
+			matched := false
+			for _, t := range group {
+				m := matchFields(e, t.score)
+				if t.negate {
+					if m.Score == 0 {
+						matched = true
+					}
+					continue
+				}
+				if m.Score == 0 {
+					continue
+				}
+				matched = true
+				result.Score += m.Score
+				switch {
+				case result.Field == "":
+					result.Field, result.Text, result.Positions = m.Field, m.Text, m.Positions
+				case result.Field == m.Field:
+					result.Positions = append(result.Positions, m.Positions...)
+				}
+			}
+			if !matched {
+				return MatchResult{}
+			}
+		}
+		if result.Score == 0 && len(groups) > 0 {
+			// Only negated terms: every surviving host matches equally.
+			result.Score = 1
+		}
+		return result
+	}
+}
@@ -0,0 +1,128 @@
+package host
+
+import (
+	"testing"
+)
+
+func modeHosts() []Host {
+	return []Host{
+		{ShortName: "db-01", MenuNumber: 1, DescText: "primary database"},
+		{ShortName: "db-02", MenuNumber: 2, DescText: "replica database"},
+		{ShortName: "web-db-0", MenuNumber: 3, DescText: "web cache"},
+		{ShortName: "dashboard", MenuNumber: 4, DescText: "metrics"},
+	}
+}
+
+func names(results []Result) map[string]bool {
+	m := make(map[string]bool)
+	for _, r := range results {
+		m[r.Host.ShortName] = true
+	}
+	return m
+}
+
+func TestParseMatchMode(t *testing.T) {
+	for i, name := range []string{"fuzzy", "exact", "prefix", "regex", "extended"} {
+		mode, err := ParseMatchMode(name)
+		if err != nil || mode != MatchMode(i) {
+			t.Errorf("%s: expected mode %d, got %d (%v)", name, i, mode, err)
+		}
+		if mode.String() != name {
+			t.Errorf("expected String() %s, got %s", name, mode.String())
+		}
+	}
+	if _, err := ParseMatchMode("bogus"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if ModeExtended.Next() != ModeFuzzy {
+		t.Error("expected Next to wrap around")
+	}
+}
+
+func TestFilterMode_Fuzzy(t *testing.T) {
+	got := names(FilterResultsMode("db0", modeHosts(), ModeFuzzy))
+	if !got["db-01"] || !got["db-02"] || !got["web-db-0"] {
+		t.Errorf("expected fuzzy match on all db hosts, got %v", got)
+	}
+}
+
+func TestFilterMode_Exact(t *testing.T) {
+	got := names(FilterResultsMode("db-0", modeHosts(), ModeExact))
+	if len(got) != 3 || got["dashboard"] {
+		t.Errorf("expected substring matches only, got %v", got)
+	}
+	got = names(FilterResultsMode("db0", modeHosts(), ModeExact))
+	if len(got) != 0 {
+		t.Errorf("expected no exact matches for db0, got %v", got)
+	}
+}
+
+func TestFilterMode_Prefix(t *testing.T) {
+	got := names(FilterResultsMode("db-0", modeHosts(), ModePrefix))
+	if len(got) != 2 || !got["db-01"] || !got["db-02"] {
+		t.Errorf("expected prefix matches db-01 and db-02, got %v", got)
+	}
+}
+
+func TestFilterMode_Regex(t *testing.T) {
+	got := names(FilterResultsMode(`^db-0[2-9]$`, modeHosts(), ModeRegex))
+	if len(got) != 1 || !got["db-02"] {
+		t.Errorf("expected regex match db-02, got %v", got)
+	}
+	results := FilterResultsMode(`rep.*base`, modeHosts(), ModeRegex)
+	if len(results) != 1 || results[0].Match.Field != FieldDescription || len(results[0].Match.Positions) != 16 {
+		t.Errorf("expected description match spanning 16 runes, got %+v", results)
+	}
+}
+
+func TestFilterMode_RegexInvalidMatchesLiterally(t *testing.T) {
+	hosts := []Host{{ShortName: "db[1]"}, {ShortName: "db1"}}
+	got := names(FilterResultsMode("db[", hosts, ModeRegex))
+	if len(got) != 1 || !got["db[1]"] {
+		t.Errorf("expected literal match for invalid pattern, got %v", got)
+	}
+}
+
+func TestFilterMode_RegexDigitsArePatterns(t *testing.T) {
+	got := names(FilterResultsMode("02", modeHosts(), ModeRegex))
+	if len(got) != 1 || !got["db-02"] {
+		t.Errorf("expected digits to match as a pattern in regex mode, got %v", got)
+	}
+}
+
+func TestFilterMode_Extended(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"'db-0", []string{"db-01", "db-02", "web-db-0"}},
+		{"^db", []string{"db-01", "db-02"}},
+		{"-0$", []string{"web-db-0"}},
+		{"^db !replica", []string{"db-01"}},
+		{"^db-01$ | ^dashboard$", []string{"db-01", "dashboard"}},
+		{"^db cache | primary", []string{"db-01"}},
+		{"!database", []string{"web-db-0", "dashboard"}},
+	}
+	for _, tt := range tests {
+		got := names(FilterResultsMode(tt.query, modeHosts(), ModeExtended))
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		for _, w := range tt.want {
+			if !got[w] {
+				t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestFilterMode_NumericShortcut(t *testing.T) {
+	for _, mode := range []MatchMode{ModeFuzzy, ModeExact, ModePrefix, ModeExtended} {
+		results := FilterResultsMode("3", modeHosts(), mode)
+		if len(results) != 1 || results[0].Host.MenuNumber != 3 {
+			t.Errorf("%s: expected menu number 3, got %v", mode, names(results))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package host
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var rePredicate = regexp.MustCompile(`^(!?)([A-Za-z][A-Za-z0-9_-]*):(.*)$`)
+
+// Predicate is a single structured filter term such as "env:prod" or "!region:us".
+type Predicate struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// Query is a parsed filter expression: structured predicates that must all
+// hold, plus free text that is matched fuzzily against the host fields.
+type Query struct {
+	Predicates []Predicate
+	Text       string
+}
+
+// queryFields are the predicate keys that name host fields rather than tags.
+var queryFields = map[string]bool{
+	"user": true, "port": true, "group": true, "host": true, "hostname": true,
+	"alias": true, "name": true, "ip": true, "is": true, "has": true, "tag": true,
+}
+
+// ParseQuery splits a filter string into predicates and free text.
+// Tokens of the form key:value become predicates when key is a host field
+// or one of tagKeys, which holds lower-cased tag keys; other tokens, such
+// as "fe80::1" or "web:8080", are joined back together as free text.
+// Negated tokens such as "!region:us" are always predicates, so they
+// exclude nothing when no host has the key. A predicate without a value
+// is still being typed and is ignored.
+func ParseQuery(s string, tagKeys map[string]bool) Query {
+	var q Query
+	var text []string
+	for _, tok := range strings.Fields(s) {
+		m := rePredicate.FindStringSubmatch(tok)
+		if m != nil {
+			key := strings.ToLower(m[2])
+			if m[1] == "" && !queryFields[key] && !tagKeys[key] {
+				m = nil
+			}
+		}
+		if m == nil {
+			text = append(text, tok)
+			continue
+		}
+		if m[3] == "" {
+			continue
+		}
+		q.Predicates = append(q.Predicates, Predicate{
+			Key:    strings.ToLower(m[2]),
+			Value:  m[3],
+			Negate: m[1] == "!",
+		})
+	}
+	q.Text = strings.Join(text, " ")
+	return q
+}
+
+// NeedsReachability reports whether any predicate depends on probe results.
+func (q Query) NeedsReachability() bool {
+	for _, p := range q.Predicates {
+		if p.Key == "is" && strings.Contains(strings.ToLower(p.Value), "reachable") {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the host satisfies every predicate in the query.
+// Free text is not considered here; see FilterHosts.
+func (q Query) Matches(h Host) bool {
+	for _, p := range q.Predicates {
+		if !p.Matches(h) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the host satisfies the predicate.
+// Values are compared case-insensitively and may contain glob wildcards.
+func (p Predicate) Matches(h Host) bool {
+	return p.match(h) != p.Negate
+}
+
+func (p Predicate) match(h Host) bool {
+	switch p.Key {
+	case "user":
+		return globMatch(p.Value, h.User)
+	case "port":
+		port := h.Port
+		if port == "" {
+			port = "22"
+		}
+		return globMatch(p.Value, port)
+	case "group":
+		for _, g := range h.Groups {
+			if globMatch(p.Value, g) {
+				return true
+			}
+		}
+		return strings.EqualFold(p.Value, "Ungrouped") && len(h.Groups) == 0
+	case "host", "hostname":
+		return globMatch(p.Value, h.LongName)
+	case "alias", "name":
+		return globMatch(p.Value, h.ShortName)
+	case "ip":
+		return globMatch(p.Value, h.IP)
+	case "is":
+		switch strings.ToLower(p.Value) {
+		case "pinned":
+			return h.Pinned
+		case "reachable":
+			return h.Reachable == ReachOK
+		case "unreachable":
+			return h.Reachable == ReachFailed
+		}
+		return false
+	case "has":
+		switch strings.ToLower(p.Value) {
+		case "warning", "warnings":
+			return len(h.Warnings) > 0
+		case "tag", "tags":
+			return len(h.Tags) > 0
+		case "group", "groups":
+			return len(h.Groups) > 0
+		case "key":
+			return h.IdentityFile != ""
+		case "ip":
+			return h.IP != ""
+		}
+		return false
+	case "tag":
+		for k := range h.Tags {
+			if globMatch(p.Value, k) {
+				return true
+			}
+		}
+		return false
+	}
+	for k, v := range h.Tags {
+		if strings.EqualFold(k, p.Key) {
+			return globMatch(p.Value, v)
+		}
+	}
+	return false
+}
+
+// globMatch matches text against a case-insensitive shell-style pattern.
+// A pattern without wildcards is a plain equality check.
+func globMatch(pattern, text string) bool {
+	pattern = strings.ToLower(pattern)
+	text = strings.ToLower(text)
+	if ok, err := path.Match(pattern, text); err == nil {
+		return ok
+	}
+	return pattern == text
+}
@@ -0,0 +1,152 @@
+package host
+
+import (
+	"testing"
+)
+
+func TestParseQuery_SplitsPredicatesAndText(t *testing.T) {
+	q := ParseQuery("env:prod web !region:us 01", map[string]bool{"env": true, "region": true})
+	if len(q.Predicates) != 2 {
+		t.Fatalf("expected 2 predicates, got %d: %v", len(q.Predicates), q.Predicates)
+	}
+	if q.Predicates[0] != (Predicate{Key: "env", Value: "prod"}) {
+		t.Errorf("unexpected first predicate: %+v", q.Predicates[0])
+	}
+	if q.Predicates[1] != (Predicate{Key: "region", Value: "us", Negate: true}) {
+		t.Errorf("unexpected second predicate: %+v", q.Predicates[1])
+	}
+	if q.Text != "web 01" {
+		t.Errorf("expected text 'web 01', got '%s'", q.Text)
+	}
+}
+
+func TestParseQuery_IgnoresIncompletePredicate(t *testing.T) {
+	q := ParseQuery("env:", map[string]bool{"env": true})
+	if len(q.Predicates) != 0 || q.Text != "" {
+		t.Errorf("expected empty query while typing, got %+v", q)
+	}
+}
+
+func TestParseQuery_UnknownKeyIsText(t *testing.T) {
+	tests := []struct {
+		query      string
+		predicates int
+		text       string
+	}{
+		{"fe80::1", 0, "fe80::1"},
+		{"web01:22", 0, "web01:22"},
+		{"!web01:22", 1, ""},
+		{"env:prod fe80::1", 1, "fe80::1"},
+		{"ENV:prod", 1, ""},
+		{"user:deploy", 1, ""},
+		{"region:", 0, "region:"},
+	}
+	for _, tt := range tests {
+		q := ParseQuery(tt.query, map[string]bool{"env": true})
+		if len(q.Predicates) != tt.predicates || q.Text != tt.text {
+			t.Errorf("%q: expected %d predicates and text %q, got %v and %q", tt.query, tt.predicates, tt.text, q.Predicates, q.Text)
+		}
+	}
+}
+
+func TestFilterHosts_IPv6Address(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "v6", IP: "fe80::1", MenuNumber: 1},
+		{ShortName: "v4", IP: "10.0.0.1", MenuNumber: 2, Tags: map[string]string{"env": "prod"}},
+	}
+	got := FilterHosts("fe80::1", hosts)
+	if len(got) != 1 || got[0].ShortName != "v6" {
+		t.Errorf("expected v6, got %v", got)
+	}
+	got = FilterHosts("env:prod", hosts)
+	if len(got) != 1 || got[0].ShortName != "v4" {
+		t.Errorf("expected v4, got %v", got)
+	}
+}
+
+func TestFilterHosts_KeyNoHostHas(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "a", MenuNumber: 1, Tags: map[string]string{"env": "prod"}},
+		{ShortName: "b", MenuNumber: 2},
+	}
+	if got := FilterHosts("!region:us", hosts); len(got) != 2 {
+		t.Errorf("expected !region:us to exclude nothing, got %v", got)
+	}
+	if got := FilterHosts("env:prod !region:us", hosts); len(got) != 1 || got[0].ShortName != "a" {
+		t.Errorf("expected a, got %v", got)
+	}
+	if got := FilterHosts("region:us", hosts); len(got) != 0 {
+		t.Errorf("expected region:us to match no host, got %v", got)
+	}
+}
+
+func TestPredicate_Fields(t *testing.T) {
+	h := Host{
+		ShortName: "db-01",
+		LongName:  "db01.example.com",
+		User:      "deploy",
+		IP:        "10.0.2.5",
+		Groups:    []string{"Infra"},
+		Tags:      map[string]string{"env": "prod", "role": "db"},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"user:deploy", true},
+		{"user:root", false},
+		{"port:22", true},
+		{"port:2222", false},
+		{"group:infra", true},
+		{"group:Web", false},
+		{"env:prod", true},
+		{"env:PROD", true},
+		{"env:stage", false},
+		{"!region:us", true},
+		{"role:d*", true},
+		{"tag:role", true},
+		{"host:*.example.com", true},
+		{"ip:10.0.2.5", true},
+		{"env:prod role:db", true},
+		{"env:prod !role:db", false},
+	}
+	tagKeys := map[string]bool{"env": true, "role": true}
+	for _, tt := range tests {
+		if got := ParseQuery(tt.query, tagKeys).Matches(h); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestPredicate_UngroupedGroup(t *testing.T) {
+	h := Host{ShortName: "a"}
+	if !ParseQuery("group:Ungrouped", nil).Matches(h) {
+		t.Error("expected host without groups to match group:Ungrouped")
+	}
+}
+
+func TestPredicate_StatusKeys(t *testing.T) {
+	h := Host{
+		ShortName: "a",
+		Pinned:    true,
+		Reachable: ReachFailed,
+		Warnings:  []Warning{{Level: "warn", Message: "x"}},
+	}
+	for _, q := range []string{"is:pinned", "is:unreachable", "has:warnings", "!is:reachable", "!has:key"} {
+		if !ParseQuery(q, nil).Matches(h) {
+			t.Errorf("%q: expected match", q)
+		}
+	}
+	if ParseQuery("is:reachable", nil).Matches(Host{ShortName: "b"}) {
+		t.Error("unprobed host should not count as reachable")
+	}
+}
+
+func TestQuery_NeedsReachability(t *testing.T) {
+	if !ParseQuery("is:unreachable", nil).NeedsReachability() {
+		t.Error("expected is:unreachable to need reachability")
+	}
+	if ParseQuery("is:pinned env:prod", nil).NeedsReachability() {
+		t.Error("expected is:pinned not to need reachability")
+	}
+}
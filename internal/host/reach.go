@@ -0,0 +1,39 @@
+package host
+
+import (
+	"net"
+	"time"
+)
+
+// Reachability records the result of probing a host's SSH port.
+type Reachability int
+
+const (
+	ReachUnknown Reachability = iota
+	ReachOK
+	ReachFailed
+)
+
+// Address returns the host:port that SSH would connect to, ignoring any
+// ProxyJump or ProxyCommand settings.
+func (h Host) Address() string {
+	name := h.LongName
+	if name == "" {
+		name = h.ShortName
+	}
+	port := h.Port
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(name, port)
+}
+
+// Probe attempts a TCP connection to the host's SSH port.
+func Probe(h Host, timeout time.Duration) Reachability {
+	conn, err := net.DialTimeout("tcp", h.Address(), timeout)
+	if err != nil {
+		return ReachFailed
+	}
+	conn.Close()
+	return ReachOK
+}
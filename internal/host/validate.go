@@ -1,7 +1,6 @@
 package host
 
 import (
-	"fmt"
 	"net"
 	"os"
 	"strings"
@@ -22,10 +21,8 @@ func checkIdentityFiles(hosts []Host) {
 		}
 		path := expandTilde(hosts[i].IdentityFile)
 		if _, err := os.Stat(path); os.IsNotExist(err) {
-			hosts[i].Warnings = append(hosts[i].Warnings, Warning{
-				Level:   "warn",
-				Message: fmt.Sprintf("Identity file not found: %s", hosts[i].IdentityFile),
-			})
+			hosts[i].Warnings = append(hosts[i].Warnings,
+				hosts[i].warning(hosts[i].Line, "Identity file not found: %s", hosts[i].IdentityFile))
 		}
 	}
 }
@@ -45,10 +42,8 @@ func checkDuplicateAliases(hosts []Host) {
 		}
 		if len(files) > 1 {
 			for _, idx := range indices {
-				hosts[idx].Warnings = append(hosts[idx].Warnings, Warning{
-					Level:   "warn",
-					Message: fmt.Sprintf("Duplicate host alias '%s' found in multiple files", name),
-				})
+				hosts[idx].Warnings = append(hosts[idx].Warnings,
+					hosts[idx].warning(hosts[idx].Line, "Duplicate host alias '%s' found in multiple files", name))
 			}
 		}
 	}
@@ -63,10 +58,8 @@ func checkEmptyHostnames(hosts []Host) {
 		if net.ParseIP(hosts[i].ShortName) != nil || strings.Contains(hosts[i].ShortName, ".") {
 			continue
 		}
-		hosts[i].Warnings = append(hosts[i].Warnings, Warning{
-			Level:   "warn",
-			Message: fmt.Sprintf("No HostName set for '%s'", hosts[i].ShortName),
-		})
+		hosts[i].Warnings = append(hosts[i].Warnings,
+			hosts[i].warning(hosts[i].Line, "No HostName set for '%s'", hosts[i].ShortName))
 	}
 }
 
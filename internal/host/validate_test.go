@@ -19,6 +19,18 @@ func TestValidateHosts_MissingIdentityFile(t *testing.T) {
 	}
 }
 
+func TestValidateHosts_WarningLocation(t *testing.T) {
+	hosts := []Host{
+		{ShortName: "a", IdentityFile: "/nonexistent/path/key", SourceFile: "config", Line: 12},
+	}
+	result := ValidateHosts(hosts)
+	for _, w := range result[0].Warnings {
+		if w.Location() != "config:12" {
+			t.Errorf("expected warning at config:12, got %q for %q", w.Location(), w.Message)
+		}
+	}
+}
+
 func TestValidateHosts_ExistingIdentityFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	keyPath := filepath.Join(tmpDir, "test_key")
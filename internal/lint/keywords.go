@@ -0,0 +1,55 @@
+package lint
+
+import "strings"
+
+// keywords maps the lowercased ssh_config(5) keywords to their canonical
+// spelling. Deprecated keywords that ssh still accepts are included, as
+// are common vendor additions, so they are not reported as unknown.
+var keywords = func() map[string]string {
+	names := []string{
+		"Host", "Match", "Include",
+		"AddKeysToAgent", "AddressFamily", "BatchMode", "BindAddress", "BindInterface",
+		"CanonicalDomains", "CanonicalizeFallbackLocal", "CanonicalizeHostname",
+		"CanonicalizeMaxDots", "CanonicalizePermittedCNAMEs", "CASignatureAlgorithms",
+		"CertificateFile", "ChannelTimeout", "CheckHostIP", "Ciphers", "ClearAllForwardings",
+		"Compression", "ConnectionAttempts", "ConnectTimeout", "ControlMaster",
+		"ControlPath", "ControlPersist", "DynamicForward", "EnableEscapeCommandline",
+		"EnableSSHKeysign", "EscapeChar", "ExitOnForwardFailure", "FingerprintHash",
+		"ForkAfterAuthentication", "ForwardAgent", "ForwardX11", "ForwardX11Timeout",
+		"ForwardX11Trusted", "GatewayPorts", "GlobalKnownHostsFile",
+		"GSSAPIAuthentication", "GSSAPIDelegateCredentials", "HashKnownHosts",
+		"HostbasedAcceptedAlgorithms", "HostbasedAuthentication", "HostKeyAlgorithms",
+		"HostKeyAlias", "HostName", "IdentitiesOnly", "IdentityAgent", "IdentityFile",
+		"IgnoreUnknown", "IPQoS", "KbdInteractiveAuthentication", "KbdInteractiveDevices",
+		"KexAlgorithms", "KnownHostsCommand", "LocalCommand", "LocalForward", "LogLevel",
+		"LogVerbose", "MACs", "NoHostAuthenticationForLocalhost", "NumberOfPasswordPrompts",
+		"ObscureKeystrokeTiming", "PasswordAuthentication", "PermitLocalCommand",
+		"PermitRemoteOpen", "PKCS11Provider", "Port", "PreferredAuthentications",
+		"ProxyCommand", "ProxyJump", "ProxyUseFdpass", "PubkeyAcceptedAlgorithms",
+		"PubkeyAuthentication", "RekeyLimit", "RemoteCommand", "RemoteForward",
+		"RequestTTY", "RequiredRSASize", "RevokedHostKeys", "SecurityKeyProvider",
+		"SendEnv", "ServerAliveCountMax", "ServerAliveInterval", "SessionType", "SetEnv",
+		"StdinNull", "StreamLocalBindMask", "StreamLocalBindUnlink",
+		"StrictHostKeyChecking", "SyslogFacility", "Tag", "TCPKeepAlive", "Tunnel",
+		"TunnelDevice", "UpdateHostKeys", "User", "UserKnownHostsFile",
+		"VerifyHostKeyDNS", "VisualHostKey", "XAuthLocation",
+		// Deprecated or vendor-specific, still accepted.
+		"ChallengeResponseAuthentication", "Cipher", "GSSAPIKeyExchange",
+		"GSSAPIRenewalForcesRekey", "GSSAPIServerIdentity", "GSSAPITrustDns",
+		"HostbasedKeyTypes", "KeepAlive", "Protocol", "PubkeyAcceptedKeyTypes",
+		"RSAAuthentication", "UseKeychain", "UsePrivilegedPort", "UseRoaming",
+	}
+	m := make(map[string]string, len(names))
+	for _, n := range names {
+		m[strings.ToLower(n)] = n
+	}
+	return m
+}()
+
+// caseSensitive lists keywords ssh-menu's parser only recognises in their
+// canonical spelling, although ssh itself ignores case.
+var caseSensitive = map[string]bool{
+	"User":         true,
+	"Port":         true,
+	"IdentityFile": true,
+}
@@ -0,0 +1,479 @@
+// Package lint checks SSH config files for mistakes that break ssh or
+// ssh-menu, reporting each with a severity and a file:line location.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+)
+
+// Severity ranks how serious a finding is.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "info"
+}
+
+// MarshalJSON encodes a severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity parses a severity name.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Info, Warning, Error} {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return Info, fmt.Errorf("unknown severity %q (want info, warning or error)", name)
+}
+
+// Finding is one problem found in a config file. Line is 0 when the
+// problem concerns the whole file.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Host     string   `json:"host,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Location returns the finding's position as file:line.
+func (f Finding) Location() string {
+	if f.Line == 0 {
+		return f.File
+	}
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Location(), f.Severity, f.Message, f.Check)
+}
+
+// Options configures Run.
+type Options struct {
+	// Resolve looks up the addresses of a host name. If nil, checks that
+	// need DNS are skipped.
+	Resolve func(name string) ([]string, error)
+}
+
+// Run checks the given config files, in the order ssh-menu reads them, and
+// returns the findings sorted by file and line.
+func Run(files []string, opts Options) []Finding {
+	l := &linter{opts: opts, fileOrder: make(map[string]int)}
+	for i, f := range files {
+		l.fileOrder[f] = i
+		l.scanFile(f)
+	}
+	l.checkBlocks()
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.File != b.File {
+			return l.fileOrder[a.File] < l.fileOrder[b.File]
+		}
+		return a.Line < b.Line
+	})
+	return l.findings
+}
+
+// Count returns how many findings are at least as severe as min.
+func Count(findings []Finding, min Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity >= min {
+			n++
+		}
+	}
+	return n
+}
+
+// annotation is an ssh-menu annotation with its line number.
+type annotation struct {
+	config.Annotation
+	line int
+}
+
+// directive is a config keyword and value with its line number.
+type directive struct {
+	keyword string
+	value   string
+	line    int
+}
+
+// block is a Host block: its patterns, the annotations before it and the
+// directives inside it.
+type block struct {
+	file        string
+	line        int
+	patterns    []string
+	annotations []annotation
+	directives  []directive
+}
+
+// alias is the name ssh-menu shows and connects to: the whole pattern list.
+func (b *block) alias() string {
+	return strings.Join(b.patterns, " ")
+}
+
+func (b *block) annotation(kind string) (annotation, bool) {
+	for _, a := range b.annotations {
+		if a.Kind == kind {
+			return a, true
+		}
+	}
+	return annotation{}, false
+}
+
+func (b *block) directive(keyword string) (directive, bool) {
+	for _, d := range b.directives {
+		if strings.EqualFold(d.keyword, keyword) {
+			return d, true
+		}
+	}
+	return directive{}, false
+}
+
+// isMenuHost reports whether ssh-menu lists the block as a host.
+func (b *block) isMenuHost() bool {
+	_, ok := b.annotation(config.AnnotationMenu)
+	return ok && b.alias() != "*"
+}
+
+type linter struct {
+	opts      Options
+	fileOrder map[string]int
+	blocks    []*block
+	ignore    []string
+	findings  []Finding
+}
+
+func (l *linter) add(sev Severity, check, file string, line int, host, format string, args ...any) {
+	l.findings = append(l.findings, Finding{
+		Severity: sev,
+		Check:    check,
+		File:     file,
+		Line:     line,
+		Host:     host,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) scanFile(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		l.add(Error, "unreadable-file", file, 0, "", "cannot read file: %v", err)
+		return
+	}
+	if info, err := os.Stat(file); err == nil && info.Mode().Perm()&0022 != 0 {
+		l.add(Error, "config-permissions", file, 0, "",
+			"file is writable by group or others (mode %04o); ssh refuses to use it", info.Mode().Perm())
+	}
+
+	var pending []annotation
+	var current *block
+	misplaced := false
+	for i, raw := range strings.Split(string(data), "\n") {
+		n := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if a, ok := config.ParseAnnotation(line); ok {
+				pending = append(pending, annotation{a, n})
+			}
+			continue
+		}
+
+		keyword, value := splitDirective(line)
+		switch strings.ToLower(keyword) {
+		case "host":
+			if keyword != "Host" {
+				l.add(Warning, "keyword-case", file, n, "",
+					"ssh-menu only recognises %q, not %q; this host is not listed", "Host", keyword)
+			}
+			b := &block{file: file, line: n, patterns: strings.Fields(value), annotations: pending}
+			l.checkHostLine(b)
+			l.blocks = append(l.blocks, b)
+			current, pending, misplaced = b, nil, false
+			continue
+		case "match":
+			if len(pending) > 0 {
+				l.add(Warning, "orphan-annotation", file, pending[0].line, "",
+					"%s annotation is followed by a Match block and is ignored", pending[0].Kind)
+			}
+			current, pending, misplaced = nil, nil, false
+			continue
+		}
+
+		if len(pending) > 0 && current != nil && !misplaced {
+			misplaced = true
+			l.add(Info, "misplaced-annotation", file, pending[0].line, current.alias(),
+				"%s annotation sits inside the block for %q; annotations describe the next Host line",
+				pending[0].Kind, current.alias())
+		}
+
+		canonical, known := keywords[strings.ToLower(keyword)]
+		switch {
+		case !known && !l.ignored(keyword):
+			l.add(Warning, "unknown-directive", file, n, "", "unknown directive %q", keyword)
+		case known && caseSensitive[canonical] && keyword != canonical:
+			l.add(Info, "keyword-case", file, n, "",
+				"ssh-menu only recognises %q, not %q; the value is not shown in the menu", canonical, keyword)
+		}
+		if strings.EqualFold(keyword, "IgnoreUnknown") {
+			l.ignore = append(l.ignore, strings.Split(value, ",")...)
+		}
+		if current != nil {
+			current.directives = append(current.directives, directive{keyword: keyword, value: value, line: n})
+		}
+	}
+
+	if len(pending) > 0 {
+		l.add(Warning, "orphan-annotation", file, pending[0].line, "",
+			"%s annotation is not followed by any Host line", pending[0].Kind)
+	}
+}
+
+// checkHostLine checks a Host line against the annotations before it.
+func (l *linter) checkHostLine(b *block) {
+	if len(b.annotations) == 0 {
+		return
+	}
+	if b.alias() == "*" {
+		l.add(Warning, "annotation-on-wildcard", b.file, b.annotations[0].line, "",
+			"annotations before 'Host *' are discarded; move them above the Host line they describe")
+		return
+	}
+	if _, ok := b.annotation(config.AnnotationMenu); !ok {
+		return
+	}
+	switch {
+	case len(b.patterns) > 1:
+		l.add(Warning, "multiple-patterns", b.file, b.line, b.alias(),
+			"Host line lists several patterns; ssh-menu connects to %q, which ssh will not match", b.alias())
+	case strings.ContainsAny(b.alias(), "*?!"):
+		l.add(Warning, "wildcard-menu-host", b.file, b.line, b.alias(),
+			"menu entry for pattern %q cannot be connected to by name", b.alias())
+	}
+}
+
+// ignored reports whether keyword matches an IgnoreUnknown pattern.
+func (l *linter) ignored(keyword string) bool {
+	for _, p := range l.ignore {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(p)), strings.ToLower(keyword)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBlocks runs checks that need every Host block.
+func (l *linter) checkBlocks() {
+	aliases := make(map[string]*block)
+	numbers := make(map[int]*block)
+	for _, b := range l.blocks {
+		for _, d := range b.directives {
+			switch strings.ToLower(d.keyword) {
+			case "identityfile":
+				l.checkIdentityFile(b, d)
+			case "proxyjump":
+				l.checkProxyJump(b, d)
+			}
+		}
+		if !b.isMenuHost() {
+			continue
+		}
+
+		alias := b.alias()
+		if first, ok := aliases[alias]; ok {
+			l.add(Warning, "duplicate-alias", b.file, b.line, alias,
+				"host %q is already defined at %s:%d", alias, first.file, first.line)
+		} else {
+			aliases[alias] = b
+		}
+
+		menu, _ := b.annotation(config.AnnotationMenu)
+		if menu.Number != 0 {
+			if first, ok := numbers[menu.Number]; ok {
+				l.add(Error, "duplicate-menu-number", b.file, menu.line, alias,
+					"menu number %d is already used by %q at %s:%d", menu.Number, first.alias(), first.file, first.line)
+			} else {
+				numbers[menu.Number] = b
+			}
+		}
+
+		l.checkHostName(b)
+	}
+}
+
+func (l *linter) checkIdentityFile(b *block, d directive) {
+	p := unquote(d.value)
+	if strings.Contains(p, "%") || strings.Contains(p, "${") || strings.EqualFold(p, "none") {
+		return
+	}
+	p = expandTilde(p)
+	info, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		l.add(Warning, "identity-missing", b.file, d.line, b.alias(), "identity file %s does not exist", d.value)
+		return
+	}
+	if err != nil {
+		l.add(Warning, "identity-unreadable", b.file, d.line, b.alias(), "cannot check identity file %s: %v", d.value, err)
+		return
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		l.add(Error, "key-permissions", b.file, d.line, b.alias(),
+			"identity file %s is accessible by others (mode %04o); ssh ignores keys that are not private",
+			d.value, info.Mode().Perm())
+	}
+}
+
+func (l *linter) checkProxyJump(b *block, d directive) {
+	if strings.EqualFold(d.value, "none") {
+		return
+	}
+	for _, hop := range strings.Split(d.value, ",") {
+		name := jumpHost(hop)
+		if name == "" || l.definesHost(name) {
+			continue
+		}
+		if l.opts.Resolve != nil {
+			if _, err := l.opts.Resolve(name); err == nil {
+				continue
+			}
+			l.add(Warning, "proxyjump-unknown", b.file, d.line, b.alias(),
+				"ProxyJump host %q is not defined in the config and does not resolve", name)
+			continue
+		}
+		// Without DNS only dotless names can be judged: they are almost
+		// always meant to be aliases.
+		if !strings.Contains(name, ".") && net.ParseIP(name) == nil {
+			l.add(Warning, "proxyjump-unknown", b.file, d.line, b.alias(),
+				"ProxyJump host %q is not defined in the config", name)
+		}
+	}
+}
+
+// definesHost reports whether any Host line matches name.
+func (l *linter) definesHost(name string) bool {
+	for _, b := range l.blocks {
+		for _, p := range b.patterns {
+			if strings.HasPrefix(p, "!") || p == "*" {
+				continue
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkHostName compares the # IP annotation with where HostName points.
+func (l *linter) checkHostName(b *block) {
+	ip, ok := b.annotation(config.AnnotationIP)
+	if !ok || net.ParseIP(ip.Value) == nil {
+		return
+	}
+	hn, ok := b.directive("HostName")
+	if !ok {
+		return
+	}
+	name := unquote(hn.value)
+	if strings.Contains(name, "%") {
+		return
+	}
+
+	if addr := net.ParseIP(name); addr != nil {
+		if !addr.Equal(net.ParseIP(ip.Value)) {
+			l.add(Warning, "ip-mismatch", b.file, ip.line, b.alias(),
+				"# IP: %s does not match HostName %s", ip.Value, name)
+		}
+		return
+	}
+	if l.opts.Resolve == nil {
+		return
+	}
+	addrs, err := l.opts.Resolve(name)
+	if err != nil {
+		l.add(Info, "ip-unresolved", b.file, hn.line, b.alias(),
+			"cannot resolve HostName %s to check # IP: %v", name, err)
+		return
+	}
+	for _, a := range addrs {
+		if net.ParseIP(a).Equal(net.ParseIP(ip.Value)) {
+			return
+		}
+	}
+	l.add(Warning, "ip-mismatch", b.file, ip.line, b.alias(),
+		"# IP: %s is not among the addresses of %s (%s)", ip.Value, name, strings.Join(addrs, ", "))
+}
+
+// splitDirective splits a config line into keyword and value, accepting
+// both "Keyword value" and "Keyword=value".
+func splitDirective(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	rest := strings.TrimLeft(line[i:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	return line[:i], strings.TrimSpace(rest)
+}
+
+// jumpHost extracts the host name from a ProxyJump hop such as
+// "user@bastion:2222" or "ssh://user@bastion:2222".
+func jumpHost(hop string) string {
+	hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+	if i := strings.LastIndex(hop, "@"); i >= 0 {
+		hop = hop[i+1:]
+	}
+	if strings.HasPrefix(hop, "[") {
+		if i := strings.Index(hop, "]"); i >= 0 {
+			return hop[1:i]
+		}
+	}
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		return host
+	}
+	return hop
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func expandTilde(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + p[1:]
+		}
+	}
+	return p
+}
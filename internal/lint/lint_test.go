@@ -0,0 +1,239 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// findChecks returns the findings for check.
+func findChecks(findings []Finding, check string) []Finding {
+	var found []Finding
+	for _, f := range findings {
+		if f.Check == check {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+func lintString(t *testing.T, content string, opts Options) []Finding {
+	t.Helper()
+	path := writeFile(t, t.TempDir(), "config", content, 0600)
+	return Run([]string{path}, opts)
+}
+
+func expectOne(t *testing.T, findings []Finding, check string, line int, sev Severity) {
+	t.Helper()
+	found := findChecks(findings, check)
+	if len(found) != 1 {
+		t.Fatalf("expected one %s finding, got %v", check, findings)
+	}
+	if found[0].Line != line || found[0].Severity != sev {
+		t.Errorf("expected %s at line %d, got %s", sev, line, found[0])
+	}
+}
+
+func TestRun_CleanConfig(t *testing.T) {
+	findings := lintString(t, `Host *
+    ServerAliveInterval 30
+
+# Menu 1: Web server
+# Group: Production
+Host web-01
+    HostName 10.0.1.5
+    User deploy
+`, Options{})
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestRun_UnreadableFile(t *testing.T) {
+	findings := Run([]string{filepath.Join(t.TempDir(), "missing")}, Options{})
+	expectOne(t, findings, "unreadable-file", 0, Error)
+}
+
+func TestRun_ConfigPermissions(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config", "# Menu: A\nHost a\n", 0666)
+	expectOne(t, Run([]string{path}, Options{}), "config-permissions", 0, Error)
+}
+
+func TestRun_UnknownDirective(t *testing.T) {
+	findings := lintString(t, `# Menu: A
+Host a
+    HostName a.example.com
+    ServerAliveIntervall 30
+`, Options{})
+	expectOne(t, findings, "unknown-directive", 4, Warning)
+}
+
+func TestRun_IgnoreUnknown(t *testing.T) {
+	findings := lintString(t, `IgnoreUnknown UseKeychain,Custom*
+Host a
+    CustomThing yes
+`, Options{})
+	if f := findChecks(findings, "unknown-directive"); len(f) != 0 {
+		t.Errorf("expected IgnoreUnknown to suppress, got %v", f)
+	}
+}
+
+func TestRun_KeyPermissions(t *testing.T) {
+	dir := t.TempDir()
+	open := writeFile(t, dir, "id_open", "key", 0644)
+	private := writeFile(t, dir, "id_private", "key", 0600)
+	findings := lintString(t, `# Menu: A
+Host a
+    IdentityFile `+open+`
+# Menu: B
+Host b
+    IdentityFile `+private+`
+    IdentityFile `+filepath.Join(dir, "id_missing")+`
+`, Options{})
+	expectOne(t, findings, "key-permissions", 3, Error)
+	expectOne(t, findings, "identity-missing", 7, Warning)
+}
+
+func TestRun_OrphanAnnotation(t *testing.T) {
+	findings := lintString(t, `# Menu: A
+Host a
+
+# Menu: Forgotten
+# Group: Old
+`, Options{})
+	expectOne(t, findings, "orphan-annotation", 4, Warning)
+}
+
+func TestRun_AnnotationBeforeWildcard(t *testing.T) {
+	findings := lintString(t, `# Menu: Defaults
+Host *
+    User admin
+`, Options{})
+	expectOne(t, findings, "annotation-on-wildcard", 1, Warning)
+}
+
+func TestRun_MisplacedAnnotation(t *testing.T) {
+	findings := lintString(t, `Host web
+    # Menu: Web server
+    HostName web.example.com
+
+Host db
+`, Options{})
+	expectOne(t, findings, "misplaced-annotation", 2, Info)
+}
+
+func TestRun_WildcardMenuHost(t *testing.T) {
+	findings := lintString(t, `# Menu: All corp hosts
+Host *.corp
+`, Options{})
+	expectOne(t, findings, "wildcard-menu-host", 2, Warning)
+}
+
+func TestRun_DuplicateMenuNumberAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	main := writeFile(t, dir, "config", "# Menu 5: A\nHost a\n", 0600)
+	team := writeFile(t, dir, "team", "\n# Menu 5: B\nHost b\n", 0600)
+	findings := Run([]string{main, team}, Options{})
+	found := findChecks(findings, "duplicate-menu-number")
+	if len(found) != 1 || found[0].File != team || found[0].Line != 2 || found[0].Severity != Error {
+		t.Fatalf("expected a duplicate-menu-number error at %s:2, got %v", team, findings)
+	}
+	if !strings.Contains(found[0].Message, main+":2") {
+		t.Errorf("expected message to point at the first definition, got %q", found[0].Message)
+	}
+}
+
+func TestRun_DuplicateAlias(t *testing.T) {
+	findings := lintString(t, `# Menu: A
+Host a
+# Menu: A again
+Host a
+`, Options{})
+	expectOne(t, findings, "duplicate-alias", 4, Warning)
+}
+
+func TestRun_ProxyJump(t *testing.T) {
+	content := `Host bastion
+    HostName bastion.example.com
+# Menu: A
+Host a
+    ProxyJump deploy@bastion:2222,jumpbox
+# Menu: B
+Host b
+    ProxyJump gw.example.com
+`
+	findings := lintString(t, content, Options{})
+	found := findChecks(findings, "proxyjump-unknown")
+	if len(found) != 1 || !strings.Contains(found[0].Message, `"jumpbox"`) || found[0].Line != 5 {
+		t.Errorf("expected only jumpbox flagged without DNS, got %v", found)
+	}
+
+	resolve := func(name string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	found = findChecks(lintString(t, content, Options{Resolve: resolve}), "proxyjump-unknown")
+	if len(found) != 2 {
+		t.Errorf("expected jumpbox and gw.example.com flagged with DNS, got %v", found)
+	}
+}
+
+func TestRun_IPMismatch(t *testing.T) {
+	content := `# Menu: A
+# IP: 10.0.0.1
+Host a
+    HostName 10.0.0.2
+# Menu: B
+# IP: 192.0.2.10
+Host b
+    HostName b.example.com
+# Menu: C
+# IP: 192.0.2.20
+Host c
+    HostName c.example.com
+`
+	resolve := func(name string) ([]string, error) {
+		return []string{"192.0.2.10"}, nil
+	}
+	found := findChecks(lintString(t, content, Options{Resolve: resolve}), "ip-mismatch")
+	if len(found) != 2 || found[0].Host != "a" || found[1].Host != "c" || found[1].Line != 10 {
+		t.Errorf("expected mismatches for a and c, got %v", found)
+	}
+}
+
+func TestRun_KeywordCase(t *testing.T) {
+	findings := lintString(t, `# Menu: A
+Host a
+    user deploy
+`, Options{})
+	expectOne(t, findings, "keyword-case", 3, Info)
+}
+
+func TestCount(t *testing.T) {
+	findings := []Finding{{Severity: Info}, {Severity: Warning}, {Severity: Error}}
+	if n := Count(findings, Warning); n != 2 {
+		t.Errorf("expected 2 findings at warning or above, got %d", n)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity("Warning"); err != nil || s != Warning {
+		t.Errorf("expected Warning, got %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
@@ -0,0 +1,294 @@
+// Package state keeps ssh-menu's per-user data outside the SSH config:
+// when each host was last connected to, persisted menu numbers, pins and
+// the order of hosts and group tabs arranged in the menu.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// State is ssh-menu's persistent per-user data, kept outside the SSH config.
+//
+// The menu reloads hosts in the background while pins are changed on the
+// UI goroutine, so a State shared between them must only be used through
+// its methods, which are safe for concurrent use. The fields are exported
+// for encoding.
+type State struct {
+	mu sync.Mutex
+	// base is the state as last loaded or saved, which Save compares
+	// against to tell this process's changes from another's.
+	base *State
+
+	// LastConnected holds when each host was last connected to, keyed by
+	// alias@source like MenuNumbers.
+	LastConnected map[string]time.Time `json:"last_connected,omitempty"`
+
+	// MenuNumbers holds auto-assigned menu numbers keyed by alias@source,
+	// so hosts keep their numbers when others are added.
+	MenuNumbers map[string]int `json:"menu_numbers,omitempty"`
+
+	// Pins holds pins set with the state pin store, keyed by alias: true
+	// pins a host and false unpins one pinned by a # Pinned comment.
+	Pins map[string]bool `json:"pins,omitempty"`
+
+	// Order lists host keys (alias@source) in the order they were
+	// arranged in the menu, and GroupOrder the group tabs.
+	Order      []string `json:"order,omitempty"`
+	GroupOrder []string `json:"group_order,omitempty"`
+}
+
+// DefaultPath returns $XDG_STATE_HOME/ssh-menu/state.json, falling back
+// to ~/.local/state when XDG_STATE_HOME is unset.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "ssh-menu", "state.json")
+}
+
+// Load reads state from path. A missing file yields empty state.
+func Load(path string) (*State, error) {
+	s := &State{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+	}
+	s.base = s.clone()
+	return s, nil
+}
+
+// Marshal returns the state as written to the state file.
+func (s *State) Marshal() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.marshal()
+}
+
+func (s *State) marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Preview returns the state as Marshal would after edit, leaving s
+// unchanged. edit is given a copy of the state.
+func (s *State) Preview(edit func(*State)) ([]byte, error) {
+	s.mu.Lock()
+	next := s.clone()
+	s.mu.Unlock()
+	edit(next)
+	return next.Marshal()
+}
+
+// clone copies the encoded fields of s. The caller must hold s.mu.
+func (s *State) clone() *State {
+	return &State{
+		LastConnected: maps.Clone(s.LastConnected),
+		MenuNumbers:   maps.Clone(s.MenuNumbers),
+		Pins:          maps.Clone(s.Pins),
+		Order:         slices.Clone(s.Order),
+		GroupOrder:    slices.Clone(s.GroupOrder),
+	}
+}
+
+// Save writes state to path, creating its directory if needed. The file
+// is replaced atomically so a crash never leaves it half-written.
+//
+// Other ssh-menu processes may have saved since s was loaded, so Save
+// re-reads the file under a lock and merges: what s changed since it was
+// loaded replaces the file's values, everything else is kept from the
+// file, and s takes on the merged state.
+func (s *State) Save(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking state file: %w", err)
+	}
+	defer unlock()
+	// A file that no longer parses is replaced rather than merged, as it
+	// was before merging.
+	if disk, err := Load(path); err == nil {
+		s.merge(disk)
+	}
+	data, err := s.marshal()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	s.base = s.clone()
+	return nil
+}
+
+// merge folds the state saved in disk into s, keeping the changes s made
+// since s.base. Connection times keep the latest of the two. The caller
+// must hold s.mu.
+func (s *State) merge(disk *State) {
+	base := s.base
+	if base == nil {
+		base = &State{}
+	}
+	for key, at := range disk.LastConnected {
+		if at.After(s.LastConnected[key]) {
+			if s.LastConnected == nil {
+				s.LastConnected = make(map[string]time.Time)
+			}
+			s.LastConnected[key] = at
+		}
+	}
+	s.MenuNumbers = mergeMap(base.MenuNumbers, s.MenuNumbers, disk.MenuNumbers)
+	s.Pins = mergeMap(base.Pins, s.Pins, disk.Pins)
+	if slices.Equal(s.Order, base.Order) {
+		s.Order = disk.Order
+	}
+	if slices.Equal(s.GroupOrder, base.GroupOrder) {
+		s.GroupOrder = disk.GroupOrder
+	}
+}
+
+// mergeMap returns disk with the entries ours added, changed or removed
+// since base applied on top.
+func mergeMap[K, V comparable](base, ours, disk map[K]V) map[K]V {
+	merged := maps.Clone(disk)
+	changed := func(k K) {
+		v, ok := ours[k]
+		if old, had := base[k]; ok == had && v == old {
+			return
+		}
+		if !ok {
+			delete(merged, k)
+			return
+		}
+		if merged == nil {
+			merged = make(map[K]V)
+		}
+		merged[k] = v
+	}
+	for k := range ours {
+		changed(k)
+	}
+	for k := range base {
+		changed(k)
+	}
+	return merged
+}
+
+// RecordConnection stores the time the host with the given key was last
+// connected to.
+func (s *State) RecordConnection(key string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.LastConnected == nil {
+		s.LastConnected = make(map[string]time.Time)
+	}
+	s.LastConnected[key] = at
+}
+
+// LastConnection returns when the host with the given key was last
+// connected to, or the zero time if it never was.
+func (s *State) LastConnection(key string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LastConnected[key]
+}
+
+// Numbers returns a copy of the persisted menu numbers.
+func (s *State) Numbers() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.MenuNumbers)
+}
+
+// SetNumbers replaces the persisted menu numbers. It reports whether they
+// changed.
+func (s *State) SetNumbers(numbers map[string]int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maps.Equal(numbers, s.MenuNumbers) {
+		return false
+	}
+	s.MenuNumbers = numbers
+	return true
+}
+
+// Arrangement returns the order of hosts and group tabs arranged in the
+// menu.
+func (s *State) Arrangement() (hosts, groups []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.Order), slices.Clone(s.GroupOrder)
+}
+
+// SetArrangement replaces the order of hosts and group tabs.
+func (s *State) SetArrangement(hosts, groups []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Order, s.GroupOrder = hosts, groups
+}
+
+// Pinned reports whether alias is pinned, given whether its config pins it
+// with a # Pinned comment. A pin in the state overrides the config.
+func (s *State) Pinned(alias string, inConfig bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pinned, ok := s.Pins[alias]; ok {
+		return pinned
+	}
+	return inConfig
+}
+
+// SetPinned pins or unpins alias. A pin that matches the config is not
+// stored, so editing the # Pinned comment later still takes effect. It
+// reports whether the stored pins changed.
+func (s *State) SetPinned(alias string, pinned, inConfig bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, had := s.Pins[alias]
+	if pinned == inConfig {
+		delete(s.Pins, alias)
+		return had
+	}
+	if s.Pins == nil {
+		s.Pins = make(map[string]bool)
+	}
+	s.Pins[alias] = pinned
+	return !had || old != pinned
+}
@@ -0,0 +1,194 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.LastConnected) != 0 {
+		t.Errorf("expected empty state, got %+v", s)
+	}
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s := &State{}
+	s.RecordConnection("web-01@config", at)
+	if err := s.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !loaded.LastConnected["web-01@config"].Equal(at) {
+		t.Errorf("expected %v, got %v", at, loaded.LastConnected["web-01@config"])
+	}
+}
+
+func TestSave_MenuNumbersRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := &State{MenuNumbers: map[string]int{"web-01@/home/u/.ssh/config": 7}}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.MenuNumbers["web-01@/home/u/.ssh/config"] != 7 {
+		t.Errorf("expected menu number 7, got %v", loaded.MenuNumbers)
+	}
+}
+
+func TestLoad_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{not json"), 0600)
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for corrupt state file")
+	}
+}
+
+func TestDefaultPath_XDG(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+	if got := DefaultPath(); got != "/tmp/xdg-state/ssh-menu/state.json" {
+		t.Errorf("unexpected path %s", got)
+	}
+}
+
+func TestPins(t *testing.T) {
+	s := &State{}
+	s.SetPinned("web-01@config", true, false)
+	s.SetPinned("db-01", false, true)
+	if !s.Pinned("web-01@config", false) {
+		t.Error("expected web-01 to be pinned by the state")
+	}
+	if s.Pinned("db-01", true) {
+		t.Error("expected the state to unpin db-01 despite its # Pinned comment")
+	}
+	if !s.Pinned("app-01", true) {
+		t.Error("expected app-01 to follow its config")
+	}
+
+	s.SetPinned("web-01@config", false, false)
+	s.SetPinned("db-01", true, true)
+	if len(s.Pins) != 0 {
+		t.Errorf("expected pins matching the config to be dropped, got %v", s.Pins)
+	}
+}
+
+func TestSave_OrderRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := &State{Order: []string{"db-01", "web-01@config"}, GroupOrder: []string{"Prod", "Dev"}}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(loaded.Order, s.Order) || !slices.Equal(loaded.GroupOrder, s.GroupOrder) {
+		t.Errorf("expected %v and %v, got %v and %v", s.Order, s.GroupOrder, loaded.Order, loaded.GroupOrder)
+	}
+}
+
+func TestSave_MergesOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	early := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	initial := &State{
+		MenuNumbers: map[string]int{"web@config": 1, "db@config": 2},
+		Pins:        map[string]bool{"db": true},
+	}
+	initial.RecordConnection("web@config", early)
+	if err := initial.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two menus load the same file and change different things.
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetPinned("web", true, false)
+	a.RecordConnection("web@config", late)
+	b.SetPinned("db", false, false)
+	b.RecordConnection("db@config", early)
+	b.SetArrangement([]string{"db@config", "web@config"}, nil)
+	if err := a.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"web": true}; !maps.Equal(loaded.Pins, want) {
+		t.Errorf("expected pins %v, got %v", want, loaded.Pins)
+	}
+	if got := loaded.LastConnection("web@config"); !got.Equal(late) {
+		t.Errorf("expected web last connected at %v, got %v", late, got)
+	}
+	if got := loaded.LastConnection("db@config"); !got.Equal(early) {
+		t.Errorf("expected db last connected at %v, got %v", early, got)
+	}
+	if want := []string{"db@config", "web@config"}; !slices.Equal(loaded.Order, want) {
+		t.Errorf("expected order %v, got %v", want, loaded.Order)
+	}
+	if len(loaded.MenuNumbers) != 2 {
+		t.Errorf("expected both menu numbers kept, got %v", loaded.MenuNumbers)
+	}
+	// The second menu now sees the first one's pin.
+	if !b.Pinned("web", false) {
+		t.Error("expected the saving state to take on the merged pins")
+	}
+}
+
+func TestSave_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := Load(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.SetPinned(fmt.Sprintf("host-%d", i), true, false)
+			if err := s.Save(path); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Pins) != 10 {
+		t.Errorf("expected every pin saved, got %v", loaded.Pins)
+	}
+}
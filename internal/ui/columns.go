@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	columnGap      = 2
+	maxColumnWidth = 32
+)
+
+// column is one configurable field in a host list row. field names the
+// host.MatchResult field the column displays, so matches can be
+// highlighted in place; offset gives where that field starts in the text.
+type column struct {
+	id     string
+	value  func(h host.Host) string
+	field  string
+	offset func(h host.Host) int
+}
+
+var columnDefs = []column{
+	{id: "alias", value: func(h host.Host) string { return h.ShortName }, field: host.FieldAlias},
+	{id: "userhost", value: userAtHost, field: host.FieldHostName, offset: func(h host.Host) int {
+		if h.User == "" {
+			return 0
+		}
+		return len([]rune(h.User)) + 1
+	}},
+	{id: "hostname", value: func(h host.Host) string { return h.LongName }, field: host.FieldHostName},
+	{id: "desc", value: func(h host.Host) string { return h.DescText }, field: host.FieldDescription},
+	{id: "groups", value: func(h host.Host) string { return strings.Join(h.Groups, ",") }, field: host.FieldGroups},
+	{id: "ip", value: func(h host.Host) string { return h.IP }, field: host.FieldIP},
+	{id: "port", value: func(h host.Host) string { return h.Port }},
+	{id: "last", value: func(h host.Host) string { return formatAgo(h.LastConnected, time.Now()) }},
+	{id: "reach", value: reachSymbol},
+}
+
+var columnAliases = map[string]string{
+	"description":    "desc",
+	"user@host":      "userhost",
+	"host":           "hostname",
+	"group":          "groups",
+	"last-connected": "last",
+	"reachability":   "reach",
+}
+
+// parseColumns resolves configured column names, ignoring unknown ones.
+// The alias column is always present and always first.
+func parseColumns(names []string) []column {
+	cols := []column{columnDefs[0]}
+	for _, name := range names {
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		for _, c := range columnDefs[1:] {
+			if c.id == name && !hasColumn(cols, name) {
+				cols = append(cols, c)
+			}
+		}
+	}
+	return cols
+}
+
+func hasColumn(cols []column, id string) bool {
+	for _, c := range cols {
+		if c.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// listLayout holds the natural width of every column, and of the menu
+// number, across all hosts of a filter result. Measuring the whole result
+// rather than the rows on screen keeps columns in place while scrolling.
+type listLayout struct {
+	numberWidth int
+	widths      []int
+}
+
+// measureColumns sizes cols to their widest value among rows, capping all
+// but the alias column at maxColumnWidth.
+func measureColumns(cols []column, rows []host.Result) listLayout {
+	l := listLayout{numberWidth: 2, widths: make([]int, len(cols))}
+	for _, r := range rows {
+		if w := len(strconv.Itoa(r.Host.MenuNumber)); w > l.numberWidth {
+			l.numberWidth = w
+		}
+	}
+	for i, c := range cols {
+		for _, r := range rows {
+			if w := runewidth.StringWidth(c.value(r.Host)); w > l.widths[i] {
+				l.widths[i] = w
+			}
+		}
+		if l.widths[i] > maxColumnWidth && i > 0 {
+			l.widths[i] = maxColumnWidth
+		}
+	}
+	return l
+}
+
+// layoutColumns takes the measured widths of cols and drops trailing
+// columns until the row fits in avail cells. The alias column is never
+// dropped; if it alone is too wide it is truncated.
+func layoutColumns(cols []column, widths []int, avail int) ([]column, []int) {
+	// Columns with nothing to show take no space.
+	kept := cols[:0:0]
+	keptWidths := widths[:0:0]
+	for i, c := range cols {
+		if i == 0 || widths[i] > 0 {
+			kept = append(kept, c)
+			keptWidths = append(keptWidths, widths[i])
+		}
+	}
+	cols, widths = kept, keptWidths
+
+	total := func() int {
+		t := 0
+		for _, w := range widths {
+			t += w
+		}
+		return t + columnGap*(len(widths)-1)
+	}
+	for len(cols) > 1 && total() > avail {
+		cols = cols[:len(cols)-1]
+		widths = widths[:len(widths)-1]
+	}
+	if total() > avail && avail > 0 {
+		widths[0] = avail
+	}
+	return cols, widths
+}
+
+func userAtHost(h host.Host) string {
+	name := h.LongName
+	if name == "" {
+		name = h.ShortName
+	}
+	if h.User == "" {
+		return name
+	}
+	return h.User + "@" + name
+}
+
+func reachSymbol(h host.Host) string {
+	switch h.Reachable {
+	case host.ReachOK:
+		return "●"
+	case host.ReachFailed:
+		return "○"
+	}
+	return "·"
+}
+
+// formatAgo renders a timestamp as a short relative age.
+func formatAgo(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func TestRenderHostList_ColumnsKeepPlaceWhileScrolling(t *testing.T) {
+	var results []host.Result
+	for i, alias := range []string{"a", "b", "a-much-longer-alias"} {
+		results = append(results, host.Result{Host: host.Host{ShortName: alias, DescText: "desc", MenuNumber: (i + 1) * 50}})
+	}
+	cols := parseColumns([]string{"desc"})
+	layout := measureColumns(cols, results)
+	if layout.numberWidth != 3 || layout.widths[0] != len("a-much-longer-alias") {
+		t.Fatalf("expected widths measured over every row, got %+v", layout)
+	}
+	column := func(scroll int) int {
+		out := renderHostList(results, cols, layout, -1, scroll, 80, 1)
+		return strings.Index(out, "desc")
+	}
+	if top, bottom := column(0), column(2); top != bottom {
+		t.Errorf("expected the description at the same column, got %d at the top and %d at the bottom", top, bottom)
+	}
+}
+
+// sgrRun matches a run of text and the SGR parameters styling it.
+var sgrRun = regexp.MustCompile(`\x1b\[([0-9;]*)m([^\x1b]*)`)
+
+// underlined returns the text rendered underlined in out, which is how
+// matched characters are highlighted.
+func underlined(out string) string {
+	var b strings.Builder
+	for _, m := range sgrRun.FindAllStringSubmatch(out, -1) {
+		if slices.Contains(strings.Split(m[1], ";"), "4") {
+			b.WriteString(m[2])
+		}
+	}
+	return b.String()
+}
+
+func TestRenderHostList_Highlight(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	tests := []struct {
+		name, alias, query, want string
+	}{
+		{name: "ascii", alias: "web-01", query: "w01", want: "w01"},
+		{name: "after multibyte runes", alias: "日本-db", query: "db", want: "db"},
+		{name: "multibyte", alias: "サーバー", query: "バー", want: "バー"},
+		{name: "number", alias: "web", query: "7", want: "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := []host.Host{{ShortName: tt.alias, DescText: "desc", MenuNumber: 7}}
+			results := host.FilterResults(tt.query, hosts)
+			if len(results) != 1 {
+				t.Fatalf("expected %q to match %q", tt.query, tt.alias)
+			}
+			cols := parseColumns([]string{"alias"})
+			out := renderHostList(results, cols, measureColumns(cols, results), 0, 0, 80, 1)
+			if got := underlined(out); got != tt.want {
+				t.Errorf("expected %q highlighted, got %q in %q", tt.want, got, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/clipboard"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// copiedMsg reports the result of copying text to the clipboard.
+type copiedMsg struct {
+	text string
+	err  error
+}
+
+// copyFormats maps the copy actions to the host format they copy.
+var copyFormats = map[keyAction]string{
+	keyCopyCommand:  host.FormatCommand,
+	keyCopyHostName: host.FormatHostName,
+	keyCopyIP:       host.FormatIP,
+	keyCopyUserHost: host.FormatUserHost,
+}
+
+// copyHost copies the selected host in the format of a copy action.
+func (m *Model) copyHost(action keyAction) tea.Cmd {
+	h := m.currentHost()
+	if h == nil {
+		return nil
+	}
+	text, err := host.Format(*h, copyFormats[action], m.verbose, m.sshOpts)
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Nothing to copy: %v", err)
+		return nil
+	}
+	out := m.terminal()
+	return func() tea.Msg {
+		return copiedMsg{text: text, err: clipboard.Copy(out, text)}
+	}
+}
+
+func (m *Model) copied(msg copiedMsg) {
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Copy failed: %v", msg.err)
+		return
+	}
+	m.statusMsg = "Copied " + msg.text
+}
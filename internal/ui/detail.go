@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -39,6 +41,7 @@ func renderDetail(h host.Host, width, height int) string {
 		{"Port", h.Port},
 		{"Key", h.IdentityFile},
 		{"IP", h.IP},
+		{"File", shortPath(h.Location())},
 	}
 
 	for _, d := range details {
@@ -56,6 +59,12 @@ func renderDetail(h host.Host, width, height int) string {
 			valueStyle.Render(strings.Join(h.Groups, ", "))))
 	}
 
+	if len(h.Tags) > 0 {
+		b.WriteString(fmt.Sprintf("%s  %s\n",
+			labelStyle.Render("Tags:"),
+			valueStyle.Render(h.TagString())))
+	}
+
 	if h.Pinned {
 		b.WriteString("\n")
 		pinStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Selected))
@@ -63,11 +72,23 @@ func renderDetail(h host.Host, width, height int) string {
 		b.WriteString("\n")
 	}
 
+	switch h.Reachable {
+	case host.ReachOK:
+		b.WriteString(theme.SelectedStyle().Render("● Reachable"))
+		b.WriteString("\n")
+	case host.ReachFailed:
+		b.WriteString(theme.WarningStyle().Render("○ Unreachable"))
+		b.WriteString("\n")
+	}
+
 	if len(h.Warnings) > 0 {
 		b.WriteString("\n")
 		warnStyle := theme.WarningStyle()
 		for _, w := range h.Warnings {
 			b.WriteString(warnStyle.Render(fmt.Sprintf("⚠ %s", w.Message)))
+			if loc := w.Location(); loc != "" {
+				b.WriteString(labelStyle.Render(" (" + shortPath(loc) + ")"))
+			}
 			b.WriteString("\n")
 		}
 	}
@@ -79,3 +100,15 @@ func renderDetail(h host.Host, width, height int) string {
 
 	return panel.Render(b.String())
 }
+
+// shortPath abbreviates the home directory in p to ~.
+func shortPath(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return p
+	}
+	if rest, ok := strings.CutPrefix(p, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return p
+}
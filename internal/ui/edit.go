@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/config"
+	"github.com/evix1101/ssh-menu/internal/editor"
+)
+
+// editorDoneMsg reports that the editor launched by editHost has exited.
+// before is the file's content when the editor was opened.
+type editorDoneMsg struct {
+	err    error
+	path   string
+	before []byte
+}
+
+// editHost suspends the UI and opens the selected host's config file in
+// $VISUAL or $EDITOR at the host's line.
+func (m *Model) editHost() tea.Cmd {
+	h := m.currentHost()
+	if h == nil {
+		return nil
+	}
+	if h.SourceFile == "" {
+		m.statusMsg = fmt.Sprintf("No config file known for %s", h.ShortName)
+		return nil
+	}
+	path := h.SourceFile
+	// A file that cannot be read now is still opened; the edit just
+	// cannot be undone.
+	before, _ := os.ReadFile(path)
+	cmd := editor.Command(path, h.Line)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorDoneMsg{err: err, path: path, before: before}
+	})
+}
+
+// editorDone reloads the config after editing. The cursor stays on the
+// host that was edited if it still exists. A change to the file is
+// recorded so it can be undone.
+func (m *Model) editorDone(msg editorDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Editor failed: %v", msg.err)
+		return nil
+	}
+	if after, err := os.ReadFile(msg.path); err == nil && msg.before != nil && !bytes.Equal(after, msg.before) {
+		m.record(operation{
+			desc: "edit of " + filepath.Base(msg.path),
+			undo: func() (tea.Cmd, error) { return m.writeConfig(msg.path, after, msg.before) },
+			redo: func() (tea.Cmd, error) { return m.writeConfig(msg.path, msg.before, after) },
+		})
+	}
+	if m.reload == nil {
+		m.statusMsg = "Restart ssh-menu to see your changes"
+		return nil
+	}
+	return m.reloadHosts()
+}
+
+// writeConfig replaces the content of a config file, failing if it is no
+// longer old, and reloads the hosts.
+func (m *Model) writeConfig(path string, old, content []byte) (tea.Cmd, error) {
+	if err := config.WriteFile(path, old, content, config.DefaultWriteOptions()); err != nil {
+		return nil, err
+	}
+	if m.reload == nil {
+		return nil, nil
+	}
+	return m.reloadHosts(), nil
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// asyncFilterThreshold is the number of hosts a filter scans above which
+// it runs off the UI goroutine. Smaller scans run inline so the list never
+// flickers.
+const asyncFilterThreshold = 2000
+
+// filterDoneMsg delivers the result of a background filter. seq identifies
+// the request so results of superseded queries can be dropped.
+type filterDoneMsg struct {
+	seq    int
+	index  *host.Index
+	result *host.Filtered
+	layout listLayout
+}
+
+// invalidateIndex discards the search index of the active view, forcing
+// it to be rebuilt from m.hosts on the next update.
+func (m *Model) invalidateIndex() {
+	m.index = nil
+	m.lastFiltered = nil
+}
+
+// updateFilteredHosts refilters the active view. Large views are filtered
+// in the background; any filter still running for an older query is
+// cancelled.
+func (m *Model) updateFilteredHosts() tea.Cmd {
+	if m.cancelFilter != nil {
+		m.cancelFilter()
+		m.cancelFilter = nil
+	}
+	m.filterSeq++
+
+	ix, prev := m.index, m.lastFiltered
+	var hosts []host.Host
+	if ix == nil {
+		// Snapshot the hosts so probes updating m.hosts cannot race with
+		// a background index build.
+		hosts = make([]host.Host, len(m.hosts))
+		copy(hosts, m.hosts)
+	}
+	var t tab
+	if m.viewIndex < len(m.tabs) {
+		t = m.tabs[m.viewIndex]
+	}
+	text, mode, seq, cols := m.filterText, m.matchMode, m.filterSeq, m.columns
+
+	run := func(ctx context.Context) tea.Msg {
+		if ix == nil {
+			ix = host.NewIndex(viewHosts(hosts, t))
+		}
+		f, err := ix.Filter(ctx, text, mode, prev)
+		if err != nil {
+			return nil
+		}
+		f.Results = host.SortResultsWithPins(f.Results)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Columns are measured here too, as every value of a large
+		// result is formatted to find the widest.
+		return filterDoneMsg{seq: seq, index: ix, result: f, layout: measureColumns(cols, f.Results)}
+	}
+
+	// Filtering scans the active view's index, or every host when the
+	// index has to be rebuilt first.
+	size := len(hosts)
+	if ix != nil {
+		size = ix.Len()
+	}
+	if size <= asyncFilterThreshold {
+		m.applyFilter(run(context.Background()).(filterDoneMsg))
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelFilter = cancel
+	return func() tea.Msg { return run(ctx) }
+}
+
+// viewHosts returns the hosts shown in tab t.
+func viewHosts(hosts []host.Host, t tab) []host.Host {
+	if t.group != "" {
+		hosts = host.HostsForGroup(hosts, t.group)
+	}
+	if t.query != "" {
+		hosts = host.FilterHosts(t.query, hosts)
+	}
+	return hosts
+}
+
+// applyFilter installs a finished filter result unless a newer query has
+// been issued since it started.
+func (m *Model) applyFilter(msg filterDoneMsg) {
+	if msg.seq != m.filterSeq {
+		return
+	}
+	m.cancelFilter = nil
+	m.index = msg.index
+	m.lastFiltered = msg.result
+	m.filtered = msg.result.Results
+	m.layout = msg.layout
+	if m.keepCursor != "" {
+		for i, r := range m.filtered {
+			if r.Host.Key() == m.keepCursor {
+				m.cursor = i
+				break
+			}
+		}
+		m.keepCursor = ""
+	}
+	m.moveCursor(0)
+}
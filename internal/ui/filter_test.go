@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func TestApplyFilter_DropsStaleResult(t *testing.T) {
+	// Enough hosts that filters run in the background.
+	var hosts []host.Host
+	for i := 1; i <= asyncFilterThreshold+1; i++ {
+		name := fmt.Sprintf("h%d", i)
+		hosts = append(hosts, host.Host{ShortName: name, DescText: name, MenuNumber: i, SourceFile: "f"})
+	}
+	m := New(hosts, Options{})
+	m.filterText = "h17"
+	if m.updateFilteredHosts() == nil {
+		t.Fatal("expected the filter to run in the background")
+	}
+	staleSeq := m.filterSeq
+	m.filterText = "h2001"
+	cmd := m.updateFilteredHosts()
+	m.Update(cmd())
+	want := listed(m)
+	if want != "h2001" {
+		t.Fatalf("expected the newer query applied, got %s", want)
+	}
+	index := m.index
+
+	// The older query finishes last.
+	m.Update(filterDoneMsg{
+		seq:    staleSeq,
+		index:  host.NewIndex(hosts),
+		result: &host.Filtered{Results: host.FilterResults("h17", hosts)},
+	})
+	if got := listed(m); got != want {
+		t.Errorf("expected the stale result dropped, got %s", got)
+	}
+	if m.index != index {
+		t.Error("expected the stale result's index dropped")
+	}
+}
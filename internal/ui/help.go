@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evix1101/ssh-menu/internal/theme"
+)
+
+var keySymbols = map[string]string{
+	"up":        "↑",
+	"down":      "↓",
+	"left":      "←",
+	"right":     "→",
+	"enter":     "Enter",
+	"esc":       "Esc",
+	"tab":       "Tab",
+	"shift+tab": "Shift+Tab",
+	"backspace": "Backspace",
+	" ":         "Space",
+}
+
+// displayKey formats a key sequence from the keymap for humans.
+func displayKey(seq string) string {
+	parts := strings.Fields(seq)
+	if seq == " " {
+		parts = []string{" "}
+	}
+	for i, p := range parts {
+		if sym, ok := keySymbols[p]; ok {
+			parts[i] = sym
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// hints returns the one-line key summary shown in the header.
+func (km *keymap) hints() string {
+	items := []struct {
+		actions []keyAction
+		label   string
+	}{
+		{[]keyAction{keyUp, keyDown}, "Navigate"},
+		{[]keyAction{keyLeft, keyRight}, "View"},
+		{[]keyAction{keyFilter}, "Filter"},
+		{[]keyAction{keyTogglePin}, "Pin"},
+		{[]keyAction{keyHelp}, "Help"},
+		{[]keyAction{keyPalette}, "Commands"},
+		{[]keyAction{keySelect}, "Select"},
+		{[]keyAction{keyCancel}, "Quit"},
+	}
+
+	var parts []string
+	for _, item := range items {
+		var keys []string
+		for _, a := range item.actions {
+			if k := km.label(a); k != "" {
+				keys = append(keys, displayKey(k))
+			}
+		}
+		if len(keys) > 0 {
+			parts = append(parts, strings.Join(keys, "/")+" "+item.label)
+		}
+	}
+	return strings.Join(parts, " • ")
+}
+
+// renderHelp lists every action with its bindings in the active keymap.
+func renderHelp(km *keymap, width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(theme.TitleStyle().Render("Key bindings"))
+	b.WriteString("\n\n")
+
+	keyWidth := 0
+	rows := make([][2]string, 0, len(actionDefs))
+	for _, d := range actionDefs {
+		var keys []string
+		for _, k := range km.keys[d.action] {
+			keys = append(keys, displayKey(k))
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		k := strings.Join(keys, ", ")
+		if w := lipgloss.Width(k); w > keyWidth {
+			keyWidth = w
+		}
+		rows = append(rows, [2]string{k, d.desc})
+	}
+
+	keyStyle := theme.SelectedStyle().Width(keyWidth)
+	for _, r := range rows {
+		b.WriteString(fmt.Sprintf("  %s  %s\n", keyStyle.Render(r[0]), theme.NormalStyle().Render(r[1])))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(theme.DimStyle().Render("In filter mode typing edits the filter and Esc returns to command mode."))
+	b.WriteString("\n")
+	b.WriteString(theme.DimStyle().Render("Press any key to close."))
+
+	return lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(b.String())
+}
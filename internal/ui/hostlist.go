@@ -7,32 +7,46 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evix1101/ssh-menu/internal/host"
 	"github.com/evix1101/ssh-menu/internal/theme"
+	"github.com/mattn/go-runewidth"
 )
 
-func renderHostList(hosts []host.Host, cursor, scrollOffset, width, height int) string {
-	if len(hosts) == 0 {
+// renderHostList draws the visible rows of results. layout is the
+// measurement of cols over all of results.
+func renderHostList(results []host.Result, cols []column, layout listLayout, cursor, scrollOffset, width, height int) string {
+	if len(results) == 0 {
 		return theme.DimStyle().Render("No hosts match your filter")
 	}
 
 	colors := theme.Current()
 	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colors.Selected))
 	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Foreground))
+	dimStyle := theme.DimStyle()
+	matchStyle := lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color(colors.Accent))
 
 	var b strings.Builder
 
 	visibleCount := height
 	if visibleCount <= 0 {
-		visibleCount = len(hosts)
+		visibleCount = len(results)
 	}
 
 	start := scrollOffset
 	end := start + visibleCount
-	if end > len(hosts) {
-		end = len(hosts)
+	if end > len(results) {
+		end = len(results)
 	}
+	if len(layout.widths) != len(cols) {
+		layout = measureColumns(cols, results)
+	}
+	numberWidth := layout.numberWidth
+	maxWidth := width - 2
+	// Pointer, pin, number, ") "
+	prefixWidth := 2 + numberWidth + 2
+	cols, widths := layoutColumns(cols, layout.widths, maxWidth-prefixWidth)
 
 	for i := start; i < end; i++ {
-		h := hosts[i]
+		h := results[i].Host
+		match := results[i].Match
 		pointer := " "
 		if i == cursor {
 			pointer = "▸"
@@ -43,24 +57,170 @@ func renderHostList(hosts []host.Host, cursor, scrollOffset, width, height int)
 			pin = "★"
 		}
 
-		line := fmt.Sprintf("%s%s%2d) %s", pointer, pin, h.MenuNumber, h.ShortName)
+		base := normalStyle
+		secondary := dimStyle
+		if i == cursor {
+			base = selectedStyle
+			secondary = selectedStyle
+		}
 
-		maxWidth := width - 2
-		if maxWidth > 0 && len(line) > maxWidth {
-			line = line[:maxWidth-1] + "…"
+		number := fmt.Sprintf("%*d", numberWidth, h.MenuNumber)
+		var numberHL []int
+		if match.Field == host.FieldNumber {
+			numberHL = shift(match.Positions, len(number)-len(match.Text))
+		}
+		segments := []segment{
+			{text: pointer + pin, style: base},
+			{text: number, style: base, hl: numberHL},
+			{text: ") ", style: base},
 		}
 
-		if i == cursor {
-			b.WriteString(selectedStyle.Render(line))
-		} else {
-			b.WriteString(normalStyle.Render(line))
+		shown := false
+		for ci, c := range cols {
+			style := secondary
+			if ci == 0 {
+				style = base
+			}
+			if c.id == "reach" {
+				style = reachStyle(h.Reachable)
+			}
+			var hl []int
+			if match.Field != "" && match.Field == c.field {
+				shown = true
+				offset := 0
+				if c.offset != nil {
+					offset = c.offset(h)
+				}
+				hl = shift(match.Positions, offset)
+			}
+			pad := widths[ci]
+			if ci == len(cols)-1 {
+				pad = 0
+			}
+			text, hl := fitCell(c.value(h), hl, widths[ci], pad)
+			if ci > 0 {
+				segments = append(segments, segment{text: strings.Repeat(" ", columnGap), style: base})
+			}
+			segments = append(segments, segment{text: text, style: style, hl: hl})
 		}
+		if !shown && match.Field != "" && match.Field != host.FieldNumber {
+			segments = append(segments,
+				segment{text: " · ", style: dimStyle},
+				segment{text: match.Text, style: dimStyle, hl: match.Positions})
+		}
+
+		b.WriteString(renderSegments(segments, maxWidth, matchStyle))
 		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
+func reachStyle(r host.Reachability) lipgloss.Style {
+	switch r {
+	case host.ReachOK:
+		return theme.SelectedStyle()
+	case host.ReachFailed:
+		return theme.WarningStyle()
+	}
+	return theme.DimStyle()
+}
+
+// fitCell truncates text to width display cells, keeping only highlight
+// positions that survive, and right-pads it to pad cells.
+func fitCell(text string, hl []int, width, pad int) (string, []int) {
+	if runewidth.StringWidth(text) > width {
+		text = runewidth.Truncate(text, width, "…")
+		n := len([]rune(text)) - 1
+		kept := hl[:0:0]
+		for _, p := range hl {
+			if p < n {
+				kept = append(kept, p)
+			}
+		}
+		hl = kept
+	}
+	if w := runewidth.StringWidth(text); w < pad {
+		text += strings.Repeat(" ", pad-w)
+	}
+	return text, hl
+}
+
+// segment is a run of text in a list row, with optional rune indices to
+// highlight as matched characters.
+type segment struct {
+	text  string
+	style lipgloss.Style
+	hl    []int
+}
+
+func shift(positions []int, by int) []int {
+	shifted := make([]int, len(positions))
+	for i, p := range positions {
+		shifted[i] = p + by
+	}
+	return shifted
+}
+
+// renderSegments styles a row, truncating it to maxWidth display cells
+// with an ellipsis.
+func renderSegments(segments []segment, maxWidth int, matchStyle lipgloss.Style) string {
+	total := 0
+	for _, s := range segments {
+		total += runewidth.StringWidth(s.text)
+	}
+	truncate := maxWidth > 0 && total > maxWidth
+
+	var b strings.Builder
+	used := 0
+	for _, s := range segments {
+		runes := []rune(s.text)
+		cut := false
+		if truncate {
+			for i, r := range runes {
+				if used+runewidth.RuneWidth(r) > maxWidth-1 {
+					runes, cut = runes[:i], true
+					break
+				}
+				used += runewidth.RuneWidth(r)
+			}
+		}
+		b.WriteString(highlightRunes(runes, s.hl, s.style, matchStyle.Inherit(s.style)))
+		if cut {
+			b.WriteString(s.style.Render("…"))
+			break
+		}
+	}
+	return b.String()
+}
+
+// highlightRunes renders runes in base style, with the runes at the given
+// indices in hl style. Consecutive runes with the same style are rendered
+// together to keep escape sequences short.
+func highlightRunes(runes []rune, positions []int, base, hl lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(string(runes))
+	}
+	marked := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		marked[p] = true
+	}
+
+	var b strings.Builder
+	runStart := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || marked[i] != marked[runStart] {
+			style := base
+			if marked[runStart] {
+				style = hl
+			}
+			b.WriteString(style.Render(string(runes[runStart:i])))
+			runStart = i
+		}
+	}
+	return b.String()
+}
+
 func calculateScrollOffset(cursor, currentOffset, visibleHeight, totalItems int) int {
 	if visibleHeight <= 0 || totalItems <= visibleHeight {
 		return 0
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// minInlineHeight is the fewest lines the inline menu uses, enough for the
+// header, the filter and a few hosts.
+const minInlineHeight = 8
+
+// Height is the size of the inline menu: a number of lines, or a
+// percentage of the terminal's height. The zero Height means full screen.
+type Height struct {
+	Value   int
+	Percent bool
+}
+
+// ParseHeight parses a height such as "15" or "40%".
+func ParseHeight(s string) (Height, error) {
+	num, percent := strings.CutSuffix(s, "%")
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 || (percent && n > 100) {
+		return Height{}, fmt.Errorf("invalid height '%s' (want lines such as 15, or a percentage such as 40%%)", s)
+	}
+	return Height{Value: n, Percent: percent}, nil
+}
+
+// inline reports whether the menu is drawn inline rather than full screen.
+func (h Height) inline() bool {
+	return h.Value > 0
+}
+
+// lines returns the menu height for a terminal that is termHeight lines tall.
+func (h Height) lines(termHeight int) int {
+	n := h.Value
+	if h.Percent {
+		n = termHeight * h.Value / 100
+	}
+	return max(min(n, termHeight), min(minInlineHeight, termHeight))
+}
+
+// quit ends the program. An inline menu clears itself first so nothing is
+// left below the prompt.
+func (m *Model) quit() tea.Cmd {
+	m.quitting = true
+	return tea.Quit
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseHeight(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Height
+		wantErr bool
+	}{
+		{in: "15", want: Height{Value: 15}},
+		{in: "40%", want: Height{Value: 40, Percent: true}},
+		{in: "100%", want: Height{Value: 100, Percent: true}},
+		{in: "0", wantErr: true},
+		{in: "-3", wantErr: true},
+		{in: "101%", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseHeight(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHeightLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		height     Height
+		termHeight int
+		want       int
+	}{
+		{name: "lines", height: Height{Value: 15}, termHeight: 40, want: 15},
+		{name: "percentage", height: Height{Value: 40, Percent: true}, termHeight: 50, want: 20},
+		{name: "raised to the minimum", height: Height{Value: 3}, termHeight: 40, want: minInlineHeight},
+		{name: "small percentage raised to the minimum", height: Height{Value: 10, Percent: true}, termHeight: 40, want: minInlineHeight},
+		{name: "capped at the terminal", height: Height{Value: 60}, termHeight: 40, want: 40},
+		{name: "terminal below the minimum", height: Height{Value: 15}, termHeight: 5, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.height.lines(tt.termHeight); got != tt.want {
+				t.Errorf("expected %d lines, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestView_Inline(t *testing.T) {
+	m := newTestModel(groupedHosts(20), Options{Height: Height{Value: 10}})
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	if got := len(strings.Split(m.View(), "\n")); got != 10 {
+		t.Errorf("expected the inline menu to use 10 lines, got %d", got)
+	}
+	m.quit()
+	if got := m.View(); got != "" {
+		t.Errorf("expected an empty view after quitting, got %q", got)
+	}
+}
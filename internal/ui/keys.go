@@ -1,46 +1,286 @@
 package ui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/config"
+)
 
 type keyAction int
 
 const (
-	keyQuit keyAction = iota
+	keyNoop keyAction = iota
+	keyQuit
+	keyCancel
 	keySelect
 	keyUp
 	keyDown
+	keyTop
+	keyBottom
+	keyPageUp
+	keyPageDown
 	keyLeft
 	keyRight
-	keyTab
-	keyBackspace
+	keyFilter
+	keyClearFilter
 	keyTogglePin
-	keyRune
-	keyNoop
+	keyHelp
+	keyPalette
+	keyMatchMode
+	keyEdit
+	keyCopyCommand
+	keyCopyHostName
+	keyCopyIP
+	keyCopyUserHost
+	keyMoveUp
+	keyMoveDown
+	keyMoveGroupLeft
+	keyMoveGroupRight
+	keyUndo
+	keyRedo
 )
 
-func classifyKey(msg tea.KeyMsg) keyAction {
-	switch msg.Type {
-	case tea.KeyEscape, tea.KeyCtrlC, tea.KeyCtrlD:
-		return keyQuit
-	case tea.KeyEnter:
-		return keySelect
-	case tea.KeyUp:
-		return keyUp
-	case tea.KeyDown:
-		return keyDown
-	case tea.KeyLeft:
-		return keyLeft
-	case tea.KeyRight:
-		return keyRight
-	case tea.KeyTab:
-		return keyTab
-	case tea.KeyBackspace:
-		return keyBackspace
-	case tea.KeyRunes:
-		if msg.String() == "p" {
-			return keyTogglePin
+// actionDef describes a bindable action. The name is what users write in
+// "# Key: name = keys" config lines. Actions that operate on the selected
+// host are hidden from the command palette when nothing is selected.
+type actionDef struct {
+	action    keyAction
+	name      string
+	desc      string
+	needsHost bool
+}
+
+// actionDefs lists every bindable action in the order shown by the help overlay.
+var actionDefs = []actionDef{
+	{keySelect, "connect", "Connect to the selected host", true},
+	{keyUp, "up", "Move up", false},
+	{keyDown, "down", "Move down", false},
+	{keyTop, "top", "Jump to the first host", false},
+	{keyBottom, "bottom", "Jump to the last host", false},
+	{keyPageUp, "page-up", "Scroll up one page", false},
+	{keyPageDown, "page-down", "Scroll down one page", false},
+	{keyLeft, "prev-view", "Previous view", false},
+	{keyRight, "next-view", "Next view", false},
+	{keyFilter, "filter", "Enter filter mode", false},
+	{keyClearFilter, "clear-filter", "Clear the filter", false},
+	{keyMatchMode, "match-mode", "Cycle match mode (fuzzy, exact, prefix, regex, extended)", false},
+	{keyTogglePin, "pin", "Pin or unpin the selected host", true},
+	{keyMoveUp, "move-up", "Move the selected host up the menu", true},
+	{keyMoveDown, "move-down", "Move the selected host down the menu", true},
+	{keyMoveGroupLeft, "move-group-left", "Move the active group tab left", false},
+	{keyMoveGroupRight, "move-group-right", "Move the active group tab right", false},
+	{keyEdit, "edit", "Edit the selected host's config in $EDITOR", true},
+	{keyCopyCommand, "copy-command", "Copy the ssh command for the selected host", true},
+	{keyCopyHostName, "copy-hostname", "Copy the selected host's HostName", true},
+	{keyCopyIP, "copy-ip", "Copy the selected host's IP address", true},
+	{keyCopyUserHost, "copy-user-host", "Copy user@hostname for the selected host", true},
+	{keyUndo, "undo", "Undo the last change saved from the menu", false},
+	{keyRedo, "redo", "Redo the last undone change", false},
+	{keyHelp, "help", "Show or hide this help", false},
+	{keyPalette, "palette", "Open the command palette", false},
+	{keyCancel, "cancel", "Clear the filter, or quit if there is none", false},
+	{keyQuit, "quit", "Quit without connecting", false},
+}
+
+// commonBindings apply to every preset.
+var commonBindings = map[keyAction][]string{
+	keySelect:    {"enter"},
+	keyUp:        {"up"},
+	keyDown:      {"down"},
+	keyTop:       {"home"},
+	keyBottom:    {"end"},
+	keyPageUp:    {"pgup"},
+	keyPageDown:  {"pgdown"},
+	keyLeft:      {"left", "shift+tab"},
+	keyRight:     {"right", "tab"},
+	keyMatchMode: {"ctrl+t"},
+	keyCancel:    {"esc"},
+	keyQuit:      {"ctrl+c"},
+
+	keyMoveUp:         {"shift+up"},
+	keyMoveDown:       {"shift+down"},
+	keyMoveGroupLeft:  {"shift+left"},
+	keyMoveGroupRight: {"shift+right"},
+}
+
+type preset struct {
+	bindings map[keyAction][]string
+	// typeToFilter makes an unbound printable key in command mode start
+	// filter mode with that character.
+	typeToFilter bool
+	// startInFilter opens the menu in filter mode.
+	startInFilter bool
+}
+
+var presets = map[string]preset{
+	"default": {
+		bindings: map[keyAction][]string{
+			keyFilter:      {"/"},
+			keyTogglePin:   {"p"},
+			keyEdit:        {"ctrl+e"},
+			keyCopyCommand: {"ctrl+y"},
+			keyUndo:        {"u"},
+			keyRedo:        {"ctrl+r"},
+			keyHelp:        {"?"},
+			keyPalette:     {"ctrl+p"},
+			keyQuit:        {"ctrl+d"},
+		},
+		typeToFilter: true,
+	},
+	"vim": {
+		bindings: map[keyAction][]string{
+			keyUp:           {"k"},
+			keyDown:         {"j"},
+			keyTop:          {"g g"},
+			keyBottom:       {"G"},
+			keyPageUp:       {"ctrl+b"},
+			keyPageDown:     {"ctrl+f"},
+			keyLeft:         {"h"},
+			keyRight:        {"l"},
+			keyFilter:       {"/"},
+			keyClearFilter:  {"ctrl+l"},
+			keyTogglePin:    {"p"},
+			keyEdit:         {"e"},
+			keyCopyCommand:  {"y y"},
+			keyCopyHostName: {"y h"},
+			keyCopyIP:       {"y i"},
+			keyCopyUserHost: {"y u"},
+			keyMoveUp:       {"K"},
+			keyMoveDown:     {"J"},
+			keyUndo:         {"u"},
+			keyRedo:         {"ctrl+r"},
+			keyHelp:         {"?"},
+			keyPalette:      {":", "ctrl+p"},
+			keyQuit:         {"q"},
+		},
+	},
+	"emacs": {
+		bindings: map[keyAction][]string{
+			keyUp:          {"ctrl+p"},
+			keyDown:        {"ctrl+n"},
+			keyTop:         {"alt+<"},
+			keyBottom:      {"alt+>"},
+			keyPageUp:      {"alt+v"},
+			keyPageDown:    {"ctrl+v"},
+			keyLeft:        {"alt+b"},
+			keyRight:       {"alt+f"},
+			keyFilter:      {"ctrl+s"},
+			keyClearFilter: {"ctrl+u"},
+			keyTogglePin:   {"alt+p"},
+			keyEdit:        {"ctrl+x ctrl+e"},
+			keyCopyCommand: {"alt+w"},
+			keyUndo:        {"ctrl+_", "ctrl+x u"},
+			keyRedo:        {"alt+ctrl+_"},
+			keyHelp:        {"alt+?"},
+			keyPalette:     {"alt+x"},
+			keyCancel:      {"ctrl+g"},
+			keyQuit:        {"ctrl+x ctrl+c"},
+		},
+		typeToFilter:  true,
+		startInFilter: true,
+	},
+}
+
+// keymap resolves key sequences to actions.
+type keymap struct {
+	bindings      map[string]keyAction
+	prefixes      map[string]bool
+	keys          map[keyAction][]string
+	typeToFilter  bool
+	startInFilter bool
+}
+
+// newKeymap builds the keymap for a preset, applying per-action overrides
+// from the config. Unknown presets fall back to "default" and unknown
+// action names are ignored.
+func newKeymap(cfg config.KeyConfig) *keymap {
+	p, ok := presets[cfg.Preset]
+	if !ok {
+		p = presets["default"]
+	}
+
+	km := &keymap{
+		bindings:      make(map[string]keyAction),
+		prefixes:      make(map[string]bool),
+		keys:          make(map[keyAction][]string),
+		typeToFilter:  p.typeToFilter,
+		startInFilter: p.startInFilter,
+	}
+	for a, keys := range commonBindings {
+		km.keys[a] = append(km.keys[a], keys...)
+	}
+	for a, keys := range p.bindings {
+		km.keys[a] = append(km.keys[a], keys...)
+	}
+	// A key given to an action by an override is taken from the action
+	// that had it, so help and hints list each key under the action it
+	// runs. When two overrides claim a key, the later action wins.
+	taken := make(map[string]keyAction)
+	for _, d := range actionDefs {
+		if keys, ok := cfg.Bindings[d.name]; ok {
+			km.keys[d.action] = keys
+			for _, k := range keys {
+				taken[k] = d.action
+			}
 		}
-		return keyRune
 	}
-	return keyNoop
+	for a, keys := range km.keys {
+		var kept []string
+		for _, k := range keys {
+			if owner, ok := taken[k]; !ok || owner == a {
+				kept = append(kept, k)
+			}
+		}
+		km.keys[a] = kept
+	}
+
+	for _, d := range actionDefs {
+		km.bind(d.action)
+	}
+	return km
+}
+
+func (km *keymap) bind(a keyAction) {
+	for _, seq := range km.keys[a] {
+		km.bindings[seq] = a
+		parts := strings.Fields(seq)
+		for i := 1; i < len(parts); i++ {
+			km.prefixes[strings.Join(parts[:i], " ")] = true
+		}
+	}
+}
+
+// resolve looks up a key pressed after an optional pending prefix. It
+// returns the action, the new pending prefix, and whether the key was
+// consumed as part of a sequence.
+func (km *keymap) resolve(pending, key string) (keyAction, string, bool) {
+	seq := key
+	if pending != "" {
+		seq = pending + " " + key
+	}
+	if a, ok := km.bindings[seq]; ok {
+		return a, "", true
+	}
+	if km.prefixes[seq] {
+		return keyNoop, seq, true
+	}
+	if pending != "" {
+		// Abandon the broken sequence and treat the key on its own.
+		return km.resolve("", key)
+	}
+	return keyNoop, "", false
+}
+
+// label returns the first key bound to an action, for hints.
+func (km *keymap) label(a keyAction) string {
+	if keys := km.keys[a]; len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// isText reports whether a key produces text for the filter.
+func isText(msg tea.KeyMsg) bool {
+	return (msg.Type == tea.KeyRunes && !msg.Alt) || msg.Type == tea.KeySpace
 }
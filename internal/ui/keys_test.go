@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/config"
+)
+
+func TestNewKeymap_Overrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string][]string
+		key      string
+		want     keyAction
+		// keys are the keys each action should list afterwards.
+		keys map[keyAction][]string
+	}{
+		{
+			name:     "free key",
+			bindings: map[string][]string{"pin": {"P"}},
+			key:      "P",
+			want:     keyTogglePin,
+			keys:     map[keyAction][]string{keyTogglePin: {"P"}},
+		},
+		{
+			name:     "key taken from another action",
+			bindings: map[string][]string{"pin": {"u"}},
+			key:      "u",
+			want:     keyTogglePin,
+			keys:     map[keyAction][]string{keyTogglePin: {"u"}, keyUndo: nil},
+		},
+		{
+			name:     "common key taken",
+			bindings: map[string][]string{"quit": {"enter"}},
+			key:      "enter",
+			want:     keyQuit,
+			keys:     map[keyAction][]string{keyQuit: {"enter"}, keySelect: nil},
+		},
+		{
+			name:     "two overrides claim a key",
+			bindings: map[string][]string{"pin": {"x", "P"}, "edit": {"x"}},
+			key:      "x",
+			want:     keyEdit,
+			keys:     map[keyAction][]string{keyTogglePin: {"P"}, keyEdit: {"x"}},
+		},
+		{
+			name:     "unknown action ignored",
+			bindings: map[string][]string{"frobnicate": {"u"}},
+			key:      "u",
+			want:     keyUndo,
+			keys:     map[keyAction][]string{keyUndo: {"u"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := newKeymap(config.KeyConfig{Bindings: tt.bindings})
+			if a, _, ok := km.resolve("", tt.key); !ok || a != tt.want {
+				t.Errorf("%s: expected action %v, got %v", tt.key, tt.want, a)
+			}
+			for a, want := range tt.keys {
+				if got := km.keys[a]; !slices.Equal(got, want) {
+					t.Errorf("action %v: expected keys %v, got %v", a, want, got)
+				}
+			}
+			for a, keys := range km.keys {
+				for _, k := range keys {
+					if got, _, _ := km.resolve("", k); got != a {
+						t.Errorf("%s is listed under action %v but runs %v", k, a, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeymap_OverrideDoesNotChangeConfig(t *testing.T) {
+	cfg := config.KeyConfig{Bindings: map[string][]string{"pin": {"x", "P"}, "edit": {"x"}}}
+	newKeymap(cfg)
+	if got := cfg.Bindings["pin"]; !slices.Equal(got, []string{"x", "P"}) {
+		t.Errorf("expected the config to be left alone, got %v", got)
+	}
+}
@@ -1,51 +1,163 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/evix1101/ssh-menu/internal/config"
 	"github.com/evix1101/ssh-menu/internal/host"
 	"github.com/evix1101/ssh-menu/internal/theme"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
 )
 
 const minWidthForTwoPane = 60
 
+// Options configures a Model.
+type Options struct {
+	Verbose     bool
+	SSHOpts     string
+	Views       []config.View
+	InitialView string
+	Keys        config.KeyConfig
+	Columns     []string
+	MatchMode   host.MatchMode
+
+	// Reload, if set, reloads the hosts after a value arrives on Changes.
+	Reload  func() ([]host.Host, error)
+	Changes <-chan struct{}
+
+	// Pin, if set, saves a pin toggled in the menu. Without it pins only
+	// last until the menu closes.
+	Pin func(h host.Host, pinned bool) error
+
+	// Order and GroupOrder are the arranged order of host keys and group
+	// tabs. SaveOrder, if set, saves them when they are rearranged.
+	Order      []string
+	GroupOrder []string
+	SaveOrder  func(hosts, groups []string) error
+
+	// Height, if set, draws the menu inline below the cursor instead of
+	// on the alternate screen.
+	Height Height
+
+	// TTY, if set, is the terminal the menu is drawn on and reads keys
+	// from instead of stdin and stdout, so stdout can be captured.
+	TTY *os.File
+}
+
+// tab is one entry in the view bar: all hosts, a saved view or a group.
+type tab struct {
+	name  string
+	query string
+	group string
+}
+
 // Model is the top-level Bubble Tea model.
 type Model struct {
-	hosts         []host.Host
-	Selected      *host.Host
-	PinToggled    bool
-	verbose       bool
-	sshOpts       string
-	cursor        int
-	scrollOffset  int
-	viewIndex     int
-	groups        []string
-	filteredHosts []host.Host
-	filterText    string
-	statusMsg     string
-	width         int
-	height        int
+	hosts        []host.Host
+	Selected     *host.Host
+	PinToggled   bool
+	verbose      bool
+	sshOpts      string
+	cursor       int
+	scrollOffset int
+	viewIndex    int
+	views        []config.View
+	tabs         []tab
+	columns      []column
+	filtered     []host.Result
+	layout       listLayout
+	index        *host.Index
+	lastFiltered *host.Filtered
+	filterSeq    int
+	cancelFilter context.CancelFunc
+	keepCursor   string
+	reload       func() ([]host.Host, error)
+	pin          func(host.Host, bool) error
+	saveOrder    func(hosts, groups []string) error
+	hostOrder    []string
+	groupOrder   []string
+	undoStack    []operation
+	redoStack    []operation
+	changes      <-chan struct{}
+	tty          *os.File
+	inline       Height
+	quitting     bool
+	filterText   string
+	filterMode   bool
+	matchMode    host.MatchMode
+	keys         *keymap
+	pendingKeys  string
+	showHelp     bool
+	palette      *palette
+	statusMsg    string
+	probing      bool
+	probeGen     int
+	probeRefresh bool
+	lastClickAt  time.Time
+	lastClickRow int
+	width        int
+	height       int
 }
 
 // New creates a new UI model.
-func New(hosts []host.Host, verbose bool, sshOpts string) *Model {
+func New(hosts []host.Host, opts Options) *Model {
 	m := &Model{
-		hosts:   hosts,
-		verbose: verbose,
-		sshOpts: sshOpts,
-		groups:  host.GetAllGroups(hosts),
+		hosts:      hosts,
+		verbose:    opts.Verbose,
+		sshOpts:    opts.SSHOpts,
+		views:      opts.Views,
+		keys:       newKeymap(opts.Keys),
+		columns:    parseColumns(opts.Columns),
+		matchMode:  opts.MatchMode,
+		reload:     opts.Reload,
+		pin:        opts.Pin,
+		saveOrder:  opts.SaveOrder,
+		hostOrder:  opts.Order,
+		groupOrder: opts.GroupOrder,
+		changes:    opts.Changes,
+		tty:        opts.TTY,
+		inline:     opts.Height,
+	}
+	m.tabs = buildTabs(opts.Views, m.groups())
+	m.filterMode = m.keys.startInFilter
+	for i, t := range m.tabs {
+		if opts.InitialView != "" && strings.EqualFold(t.name, opts.InitialView) {
+			m.viewIndex = i
+		}
 	}
-	m.updateFilteredHosts()
 	return m
 }
 
+func buildTabs(views []config.View, groups []string) []tab {
+	tabs := []tab{{name: "All"}}
+	for _, v := range views {
+		tabs = append(tabs, tab{name: v.Name, query: v.Query})
+	}
+	for _, g := range groups {
+		tabs = append(tabs, tab{name: g, group: g})
+	}
+	return tabs
+}
+
 // Run starts the Bubble Tea program.
 func Run(m *Model) error {
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	var popts []tea.ProgramOption
+	if !m.inline.inline() {
+		// Mouse rows are only known relative to the full screen.
+		popts = append(popts, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+	if m.tty != nil {
+		popts = append(popts, tea.WithInput(m.tty), tea.WithOutput(m.tty))
+		lipgloss.DefaultRenderer().SetOutput(termenv.NewOutput(m.tty))
+	}
+	p := tea.NewProgram(m, popts...)
 	finalModel, err := p.Run()
 	if err != nil {
 		return err
@@ -57,9 +169,17 @@ func Run(m *Model) error {
 	return nil
 }
 
+// terminal returns the file the menu is drawn on.
+func (m *Model) terminal() *os.File {
+	if m.tty != nil {
+		return m.tty
+	}
+	return os.Stdout
+}
+
 // Init implements tea.Model.
 func (m *Model) Init() tea.Cmd {
-	return tea.WindowSize()
+	return tea.Batch(tea.WindowSize(), m.updateFilteredHosts(), m.startProbes(), waitForChange(m.changes))
 }
 
 // Update implements tea.Model.
@@ -68,63 +188,163 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.inline.inline() {
+			m.height = m.inline.lines(msg.Height)
+		}
 		m.recalcScroll()
 		return m, nil
 	case tea.KeyMsg:
 		return m.handleKey(msg)
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+	case probeMsg:
+		return m, m.applyProbes(msg)
+	case probeRefreshMsg:
+		return m, m.refreshProbes()
+	case filterDoneMsg:
+		m.applyFilter(msg)
+		return m, nil
+	case configChangedMsg:
+		return m, tea.Batch(m.reloadHosts(), waitForChange(m.changes))
+	case reloadMsg:
+		return m, m.applyReload(msg)
+	case editorDoneMsg:
+		return m, m.editorDone(msg)
+	case copiedMsg:
+		m.copied(msg)
+		return m, nil
 	}
 	return m, nil
 }
 
 func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	action := classifyKey(msg)
+	// Clear status message on any key press
+	m.statusMsg = ""
 
-	// When filter is active, 'p' is just a character, not pin toggle
-	if action == keyTogglePin && m.filterText != "" {
-		action = keyRune
+	if m.showHelp {
+		m.showHelp = false
+		return m, nil
+	}
+	if m.palette != nil {
+		return m.handlePaletteKey(msg)
 	}
 
-	// Clear status message on any key press
-	m.statusMsg = ""
+	// In filter mode printable keys are text; everything else still goes
+	// through the keymap so navigation keeps working while typing.
+	if m.filterMode {
+		switch {
+		case msg.Type == tea.KeyEscape:
+			m.filterMode = false
+			m.pendingKeys = ""
+			return m, nil
+		case msg.Type == tea.KeyBackspace:
+			return m, m.handleBackspace()
+		case isText(msg):
+			return m, m.setFilter(m.filterText + msg.String())
+		}
+	}
+
+	action, pending, consumed := m.keys.resolve(m.pendingKeys, msg.String())
+	m.pendingKeys = pending
+	if !consumed {
+		switch {
+		case msg.Type == tea.KeyBackspace:
+			return m, m.handleBackspace()
+		case m.keys.typeToFilter && isText(msg):
+			m.filterMode = true
+			return m, m.setFilter(m.filterText + msg.String())
+		}
+		return m, nil
+	}
+	return m.runAction(action)
+}
 
+func (m *Model) runAction(action keyAction) (tea.Model, tea.Cmd) {
 	switch action {
 	case keyQuit:
-		return m, tea.Quit
+		return m, m.quit()
+	case keyCancel:
+		if m.filterText != "" {
+			return m, m.setFilter("")
+		}
+		return m, m.quit()
 	case keySelect:
 		return m.selectHost()
 	case keyUp:
 		m.moveCursor(-1)
 	case keyDown:
 		m.moveCursor(1)
+	case keyTop:
+		m.moveCursor(-len(m.filtered))
+	case keyBottom:
+		m.moveCursor(len(m.filtered))
+	case keyPageUp:
+		m.moveCursor(-m.contentHeight())
+	case keyPageDown:
+		m.moveCursor(m.contentHeight())
 	case keyLeft:
-		m.navigateView(-1)
+		return m, m.navigateView(-1)
 	case keyRight:
-		m.navigateView(1)
-	case keyTab:
-		m.navigateView(1)
-	case keyBackspace:
-		m.handleBackspace()
+		return m, m.navigateView(1)
+	case keyFilter:
+		m.filterMode = true
+	case keyClearFilter:
+		return m, m.setFilter("")
+	case keyMatchMode:
+		m.matchMode = m.matchMode.Next()
+		m.statusMsg = fmt.Sprintf("Match mode: %s", m.matchMode)
+		return m, m.setFilter(m.filterText)
 	case keyTogglePin:
-		m.togglePin()
-	case keyRune:
-		m.filterText += msg.String()
-		m.updateFilteredHosts()
-		m.cursor = 0
-		m.scrollOffset = 0
+		return m, m.togglePin()
+	case keyMoveUp:
+		return m, m.moveHost(-1)
+	case keyMoveDown:
+		return m, m.moveHost(1)
+	case keyMoveGroupLeft:
+		return m, m.moveGroup(-1)
+	case keyMoveGroupRight:
+		return m, m.moveGroup(1)
+	case keyUndo:
+		return m, m.undo()
+	case keyRedo:
+		return m, m.redo()
+	case keyEdit:
+		return m, m.editHost()
+	case keyCopyCommand, keyCopyHostName, keyCopyIP, keyCopyUserHost:
+		return m, m.copyHost(action)
+	case keyHelp:
+		m.showHelp = true
+	case keyPalette:
+		m.openPalette()
 	case keyNoop:
-		// Ignore unhandled keys
+		// Pending key sequence
 	}
 	return m, nil
 }
 
+func (m *Model) setFilter(text string) tea.Cmd {
+	m.filterText = text
+	m.cursor = 0
+	m.scrollOffset = 0
+	return tea.Batch(m.updateFilteredHosts(), m.startProbes())
+}
+
+// currentHost returns the host under the cursor, or nil if the list is empty.
+func (m *Model) currentHost() *host.Host {
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	return &m.filtered[m.cursor].Host
+}
+
 func (m *Model) selectHost() (tea.Model, tea.Cmd) {
-	if len(m.filteredHosts) == 1 {
-		m.Selected = &m.filteredHosts[0]
-		return m, tea.Quit
+	if len(m.filtered) == 1 {
+		m.Selected = &m.filtered[0].Host
+		return m, m.quit()
 	}
-	if len(m.filteredHosts) > 0 && m.cursor < len(m.filteredHosts) {
-		m.Selected = &m.filteredHosts[m.cursor]
-		return m, tea.Quit
+	if len(m.filtered) > 0 && m.cursor < len(m.filtered) {
+		m.Selected = &m.filtered[m.cursor].Host
+		return m, m.quit()
 	}
 	return m, nil
 }
@@ -134,8 +354,8 @@ func (m *Model) moveCursor(delta int) {
 	if m.cursor < 0 {
 		m.cursor = 0
 	}
-	if m.cursor >= len(m.filteredHosts) {
-		m.cursor = len(m.filteredHosts) - 1
+	if m.cursor >= len(m.filtered) {
+		m.cursor = len(m.filtered) - 1
 	}
 	if m.cursor < 0 {
 		m.cursor = 0
@@ -143,8 +363,8 @@ func (m *Model) moveCursor(delta int) {
 	m.recalcScroll()
 }
 
-func (m *Model) navigateView(delta int) {
-	totalViews := 1 + len(m.groups)
+func (m *Model) navigateView(delta int) tea.Cmd {
+	totalViews := len(m.tabs)
 	m.viewIndex += delta
 	if m.viewIndex < 0 {
 		m.viewIndex = totalViews - 1
@@ -154,88 +374,146 @@ func (m *Model) navigateView(delta int) {
 	m.filterText = ""
 	m.cursor = 0
 	m.scrollOffset = 0
-	m.updateFilteredHosts()
+	m.invalidateIndex()
+	return tea.Batch(m.updateFilteredHosts(), m.startProbes())
 }
 
-func (m *Model) handleBackspace() {
-	if len(m.filterText) > 0 {
-		m.filterText = m.filterText[:len(m.filterText)-1]
-		m.updateFilteredHosts()
-		m.cursor = 0
-		m.scrollOffset = 0
+func (m *Model) handleBackspace() tea.Cmd {
+	if len(m.filterText) == 0 {
+		return nil
 	}
+	runes := []rune(m.filterText)
+	return m.setFilter(string(runes[:len(runes)-1]))
 }
 
-func (m *Model) togglePin() {
-	if len(m.filteredHosts) == 0 || m.cursor >= len(m.filteredHosts) {
-		return
+func (m *Model) togglePin() tea.Cmd {
+	if len(m.filtered) == 0 || m.cursor >= len(m.filtered) {
+		return nil
 	}
-	selected := &m.filteredHosts[m.cursor]
-	newPinState := !selected.Pinned
+	selected := m.filtered[m.cursor].Host
+	pinned := !selected.Pinned
 
-	if selected.SourceFile != "" {
-		if err := config.TogglePin(selected.SourceFile, selected.ShortName, newPinState); err != nil {
-			m.statusMsg = fmt.Sprintf("Pin failed: %v", err)
-			return
-		}
+	cmd, err := m.setPinned(selected, pinned)
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Pin failed: %v", err)
+		return nil
+	}
+	desc := "pin " + selected.ShortName
+	if !pinned {
+		desc = "unpin " + selected.ShortName
 	}
+	m.record(operation{
+		desc: desc,
+		undo: func() (tea.Cmd, error) { return m.setPinned(selected, !pinned) },
+		redo: func() (tea.Cmd, error) { return m.setPinned(selected, pinned) },
+	})
+	return cmd
+}
 
+// setPinned saves a pin and applies it to the host in the menu. h may be
+// a copy taken before an earlier change, so the current host is used.
+func (m *Model) setPinned(h host.Host, pinned bool) (tea.Cmd, error) {
+	for _, cur := range m.hosts {
+		if cur.Key() == h.Key() {
+			h = cur
+			break
+		}
+	}
+	if m.pin != nil {
+		if err := m.pin(h, pinned); err != nil {
+			return nil, err
+		}
+	}
 	for i := range m.hosts {
-		if m.hosts[i].ShortName == selected.ShortName && m.hosts[i].SourceFile == selected.SourceFile {
-			m.hosts[i].Pinned = newPinState
+		if m.hosts[i].Key() == h.Key() {
+			m.hosts[i].Pinned = pinned
 		}
 	}
-
 	m.PinToggled = true
-	m.updateFilteredHosts()
+	m.invalidateIndex()
+	return m.updateFilteredHosts(), nil
 }
 
-func (m *Model) updateFilteredHosts() {
-	var viewHosts []host.Host
-	if m.viewIndex == 0 {
-		viewHosts = m.hosts
-	} else {
-		groupIndex := m.viewIndex - 1
-		if groupIndex < len(m.groups) {
-			viewHosts = host.HostsForGroup(m.hosts, m.groups[groupIndex])
+// listTop returns the screen row where the host list starts: the number
+// of rows the header written by View takes on screen.
+func (m *Model) listTop() int {
+	header, _ := m.header()
+	return m.screenRows(header)
+}
+
+// screenRows returns how many terminal rows s covers, counting lines wider
+// than the terminal as wrapping onto further rows.
+func (m *Model) screenRows(s string) int {
+	rows := 0
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		w := lipgloss.Width(line)
+		if m.width > 0 && w > m.width {
+			rows += (w + m.width - 1) / m.width
+		} else {
+			rows++
 		}
 	}
-
-	filtered := host.FilterHosts(m.filterText, viewHosts)
-	m.filteredHosts = host.SortWithPins(filtered)
+	return rows
 }
 
 func (m *Model) contentHeight() int {
-	headerLines := 3
-	if m.filterText != "" {
-		headerLines += 2
-	}
-	if m.statusMsg != "" {
-		headerLines++
-	}
-	h := m.height - headerLines
+	h := m.height - m.listTop()
 	if h < 1 {
+		if m.inline.inline() {
+			return 1
+		}
 		h = 20
 	}
 	return h
 }
 
+// listWidth returns the width of the host list pane.
+func (m *Model) listWidth() int {
+	if m.width >= minWidthForTwoPane {
+		return m.width * 55 / 100
+	}
+	return m.width
+}
+
 func (m *Model) recalcScroll() {
-	m.scrollOffset = calculateScrollOffset(m.cursor, m.scrollOffset, m.contentHeight(), len(m.filteredHosts))
+	m.scrollOffset = calculateScrollOffset(m.cursor, m.scrollOffset, m.contentHeight(), len(m.filtered))
 }
 
 // View implements tea.Model.
 func (m *Model) View() string {
+	if !m.inline.inline() {
+		return m.render()
+	}
+	if m.quitting {
+		return ""
+	}
+	// An inline menu must stay within its height: lines that scroll off
+	// the terminal can no longer be redrawn or cleared.
+	lines := strings.Split(strings.TrimSuffix(m.render(), "\n"), "\n")
+	if len(lines) > m.height {
+		lines = lines[:m.height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// header renders the rows above the host list: the title, the view bar,
+// the filter and the status message. barRow is the screen row of the view
+// bar, or -1 when there is no bar.
+func (m *Model) header() (string, int) {
 	colors := theme.Current()
 	var s strings.Builder
+	barRow := -1
 
-	helpText := "↑/↓ Navigate • ←/→ View • p Pin • Enter Select • Esc Quit"
-	helpWidth := lipgloss.Width(helpText)
+	helpText := m.keys.hints()
 	title := "SSH Menu"
 	titleWidth := lipgloss.Width(title)
+	if m.width > 0 && titleWidth+lipgloss.Width(helpText)+2 > m.width {
+		helpText = runewidth.Truncate(helpText, m.width-titleWidth-2, "…")
+	}
+	helpWidth := lipgloss.Width(helpText)
 
 	spacing := ""
-	if m.width > 0 && m.width > titleWidth+helpWidth+2 {
+	if m.width > 0 && m.width >= titleWidth+helpWidth+2 {
 		spacing = strings.Repeat(" ", m.width-titleWidth-helpWidth)
 	}
 
@@ -244,17 +522,27 @@ func (m *Model) View() string {
 	s.WriteString(theme.DimStyle().Render(helpText))
 	s.WriteString("\n")
 
-	if len(m.groups) > 0 {
-		s.WriteString(renderViewBar(m.groups, m.viewIndex))
+	if len(m.tabs) > 1 {
+		barRow = m.screenRows(s.String())
+		s.WriteString(renderViewBar(m.tabNames(), m.viewIndex, m.width))
 		s.WriteString("\n")
 	}
 	s.WriteString("\n")
 
-	if m.filterText != "" {
+	if m.filterText != "" || m.filterMode {
 		filterStyle := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color(colors.Accent))
-		s.WriteString(filterStyle.Render(fmt.Sprintf("Filter: %s", m.filterText)))
+		label := "Filter"
+		if m.matchMode != host.ModeFuzzy {
+			label = fmt.Sprintf("Filter (%s)", m.matchMode)
+		}
+		if m.filterMode {
+			s.WriteString(filterStyle.Render(fmt.Sprintf("%s: %s▏", label, m.filterText)))
+		} else {
+			s.WriteString(filterStyle.Render(fmt.Sprintf("%s: %s", label, m.filterText)))
+			s.WriteString(theme.DimStyle().Render(fmt.Sprintf("  (%s to edit)", displayKey(m.keys.label(keyFilter)))))
+		}
 		s.WriteString("\n\n")
 	}
 
@@ -262,18 +550,35 @@ func (m *Model) View() string {
 		s.WriteString(theme.WarningStyle().Render(m.statusMsg))
 		s.WriteString("\n")
 	}
+	return s.String(), barRow
+}
+
+func (m *Model) render() string {
+	colors := theme.Current()
+	var s strings.Builder
+	header, _ := m.header()
+	s.WriteString(header)
 
 	ch := m.contentHeight()
 
+	if m.showHelp {
+		s.WriteString(renderHelp(m.keys, m.width, ch))
+		return s.String()
+	}
+	if m.palette != nil {
+		s.WriteString(renderPalette(m.palette, m.width, ch))
+		return s.String()
+	}
+
 	if m.width >= minWidthForTwoPane {
-		leftWidth := m.width * 55 / 100
+		leftWidth := m.listWidth()
 		rightWidth := m.width - leftWidth - 1
 
-		leftPane := renderHostList(m.filteredHosts, m.cursor, m.scrollOffset, leftWidth, ch)
+		leftPane := renderHostList(m.filtered, m.columns, m.layout, m.cursor, m.scrollOffset, leftWidth, ch)
 
 		rightPane := ""
-		if len(m.filteredHosts) > 0 && m.cursor < len(m.filteredHosts) {
-			rightPane = renderDetail(m.filteredHosts[m.cursor], rightWidth, ch)
+		if len(m.filtered) > 0 && m.cursor < len(m.filtered) {
+			rightPane = renderDetail(m.filtered[m.cursor].Host, rightWidth, ch)
 		}
 
 		separator := lipgloss.NewStyle().
@@ -301,12 +606,20 @@ func (m *Model) View() string {
 			s.WriteString("\n")
 		}
 	} else {
-		s.WriteString(renderHostList(m.filteredHosts, m.cursor, m.scrollOffset, m.width, ch))
+		s.WriteString(renderHostList(m.filtered, m.columns, m.layout, m.cursor, m.scrollOffset, m.width, ch))
 	}
 
 	return s.String()
 }
 
+func (m *Model) tabNames() []string {
+	names := make([]string, len(m.tabs))
+	for i, t := range m.tabs {
+		names[i] = t.name
+	}
+	return names
+}
+
 func safeGet(lines []string, i int) string {
 	if i < len(lines) {
 		return lines[i]
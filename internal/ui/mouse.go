@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	wheelStep           = 3
+	doubleClickInterval = 400 * time.Millisecond
+)
+
+func (m *Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.showHelp || m.palette != nil {
+		return m, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.scrollBy(-wheelStep)
+		return m, nil
+	case tea.MouseButtonWheelDown:
+		m.scrollBy(wheelStep)
+		return m, nil
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+	default:
+		return m, nil
+	}
+
+	header, barRow := m.header()
+	if barRow >= 0 && msg.Y == barRow {
+		if i := viewBarTabAt(m.tabNames(), m.viewIndex, m.width, msg.X); i >= 0 && i != m.viewIndex {
+			return m, m.navigateView(i - m.viewIndex)
+		}
+		return m, nil
+	}
+
+	row := msg.Y - m.screenRows(header)
+	if row < 0 || row >= m.contentHeight() || msg.X >= m.listWidth() {
+		return m, nil
+	}
+	idx := m.scrollOffset + row
+	if idx >= len(m.filtered) {
+		return m, nil
+	}
+
+	now := time.Now()
+	double := idx == m.lastClickRow && now.Sub(m.lastClickAt) < doubleClickInterval
+	m.lastClickAt, m.lastClickRow = now, idx
+	m.cursor = idx
+	if double {
+		return m.selectHost()
+	}
+	return m, nil
+}
+
+// scrollBy moves the list viewport without moving the cursor, except to
+// keep the cursor on screen.
+func (m *Model) scrollBy(delta int) {
+	visible := m.contentHeight()
+	maxOffset := len(m.filtered) - visible
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	m.scrollOffset += delta
+	if m.scrollOffset > maxOffset {
+		m.scrollOffset = maxOffset
+	}
+	if m.scrollOffset < 0 {
+		m.scrollOffset = 0
+	}
+	if m.cursor < m.scrollOffset {
+		m.cursor = m.scrollOffset
+	}
+	if m.cursor >= m.scrollOffset+visible {
+		m.cursor = m.scrollOffset + visible - 1
+	}
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// groupedHosts returns one host in each of n groups named group-01 and up.
+func groupedHosts(n int) []host.Host {
+	var hosts []host.Host
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("h%d", i)
+		hosts = append(hosts, host.Host{
+			ShortName: name, DescText: name, MenuNumber: i, SourceFile: "f",
+			Groups: []string{fmt.Sprintf("group-%02d", i)},
+		})
+	}
+	return hosts
+}
+
+func click(x, y int) tea.MouseMsg {
+	return tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+}
+
+func TestLayoutViewBar_FitsWidth(t *testing.T) {
+	names := []string{"All"}
+	for i := 1; i <= 9; i++ {
+		names = append(names, fmt.Sprintf("group-%02d", i))
+	}
+	for active := range names {
+		bar := renderViewBar(names, active, 40)
+		if w := lipgloss.Width(bar); w > 40 {
+			t.Errorf("active %d: bar is %d columns wide, want at most 40", active, w)
+		}
+		if !strings.Contains(bar, names[active]) {
+			t.Errorf("active %d: bar %q does not show the active tab", active, bar)
+		}
+	}
+	if got := len(layoutViewBar(names, 0, 0)); got != len(names) {
+		t.Errorf("expected every tab without a width, got %d", got)
+	}
+}
+
+func TestHandleMouse_ClickTab(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups int
+		width  int
+		active int
+		target int
+	}{
+		{name: "bar fits", groups: 3, width: 80, active: 0, target: 2},
+		{name: "scrolled bar", groups: 9, width: 40, active: 9, target: 8},
+		{name: "scrolled to the start", groups: 9, width: 40, active: 0, target: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(groupedHosts(tt.groups), Options{})
+			m.width, m.height = tt.width, 24
+			m.viewIndex = tt.active
+			x := -1
+			for _, tab := range layoutViewBar(m.tabNames(), m.viewIndex, m.width) {
+				if tab.index == tt.target {
+					x = tab.x
+				}
+			}
+			if x < 0 {
+				t.Fatalf("tab %d is not on the bar", tt.target)
+			}
+			// The bar sits under the title line.
+			m.handleMouse(click(x, 1))
+			if m.viewIndex != tt.target {
+				t.Errorf("expected tab %d, got %d", tt.target, m.viewIndex)
+			}
+		})
+	}
+}
+
+func TestHandleMouse_ClickRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter bool
+		status string
+		y      int
+	}{
+		{name: "view bar", y: 4},
+		{name: "filter and status", filter: true, status: "Pinned h1", y: 7},
+		// A status message wider than the terminal wraps onto a second row.
+		{name: "wrapped status", status: strings.Repeat("x", 50), y: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(groupedHosts(3), Options{})
+			m.width, m.height = 40, 24
+			m.filterMode = tt.filter
+			m.statusMsg = tt.status
+			if got := m.listTop(); got != tt.y-1 {
+				t.Errorf("expected the list at row %d, got %d", tt.y-1, got)
+			}
+			if got := strings.Count(m.render(), "\n"); got < tt.y {
+				t.Fatalf("view has only %d lines", got)
+			}
+			m.handleMouse(click(2, tt.y))
+			if m.cursor != 1 {
+				t.Errorf("expected a click on row %d to select the second host, got %d", tt.y, m.cursor)
+			}
+		})
+	}
+}
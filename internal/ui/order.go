@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// groups returns the group tabs in the order arranged by the user.
+func (m *Model) groups() []string {
+	return host.OrderGroups(host.GetAllGroups(m.hosts), m.groupOrder)
+}
+
+// moveHost swaps the selected host with the one delta rows away in the
+// list. The whole menu order is saved, so the two also swap places in
+// views where they are not neighbours.
+func (m *Model) moveHost(delta int) tea.Cmd {
+	cur := m.currentHost()
+	j := m.cursor + delta
+	if cur == nil || j < 0 || j >= len(m.filtered) {
+		return nil
+	}
+	other := m.filtered[j].Host
+	if other.Pinned != cur.Pinned {
+		m.statusMsg = "Pinned hosts stay above the others"
+		return nil
+	}
+
+	prev := m.hostOrder
+	order := host.Ordering(m.hosts)
+	a, b := slices.Index(order, cur.Key()), slices.Index(order, other.Key())
+	order[a], order[b] = order[b], order[a]
+
+	// The cursor follows the moved host, also when the move is undone.
+	key := cur.Key()
+	move := func(order []string) (tea.Cmd, error) {
+		m.keepCursor = key
+		return m.setOrder(order, m.groupOrder)
+	}
+	cmd, err := move(order)
+	if err != nil {
+		m.statusMsg = "Move failed: " + err.Error()
+		return nil
+	}
+	desc := "move " + cur.ShortName + " down"
+	if delta < 0 {
+		desc = "move " + cur.ShortName + " up"
+	}
+	m.record(operation{
+		desc: desc,
+		undo: func() (tea.Cmd, error) { return move(prev) },
+		redo: func() (tea.Cmd, error) { return move(order) },
+	})
+	return cmd
+}
+
+// moveGroup moves the active group tab delta places along the view bar.
+func (m *Model) moveGroup(delta int) tea.Cmd {
+	if m.viewIndex >= len(m.tabs) || m.tabs[m.viewIndex].group == "" {
+		m.statusMsg = "Only group tabs can be moved"
+		return nil
+	}
+	name := m.tabs[m.viewIndex].group
+	order := m.groups()
+	i := slices.Index(order, name)
+	j := i + delta
+	if j < 0 || j >= len(order) {
+		return nil
+	}
+	order[i], order[j] = order[j], order[i]
+
+	prev := m.groupOrder
+	cmd, err := m.setOrder(m.hostOrder, order)
+	if err != nil {
+		m.statusMsg = "Move failed: " + err.Error()
+		return nil
+	}
+	desc := "move group " + name + " right"
+	if delta < 0 {
+		desc = "move group " + name + " left"
+	}
+	m.record(operation{
+		desc: desc,
+		undo: func() (tea.Cmd, error) { return m.setOrder(m.hostOrder, prev) },
+		redo: func() (tea.Cmd, error) { return m.setOrder(m.hostOrder, order) },
+	})
+	return cmd
+}
+
+// setOrder saves the order of hosts and group tabs and applies it to the
+// menu, keeping the active tab.
+func (m *Model) setOrder(hosts, groups []string) (tea.Cmd, error) {
+	if m.saveOrder != nil {
+		if err := m.saveOrder(hosts, groups); err != nil {
+			return nil, err
+		}
+	}
+	m.hostOrder, m.groupOrder = hosts, groups
+	host.ApplyOrder(m.hosts, hosts)
+	// Group tabs follow the views, so only a group tab can change place.
+	active := m.tabs[m.viewIndex].group
+	m.tabs = buildTabs(m.views, m.groups())
+	for i, t := range m.tabs {
+		if active != "" && t.group == active {
+			m.viewIndex = i
+		}
+	}
+	m.invalidateIndex()
+	return m.updateFilteredHosts(), nil
+}
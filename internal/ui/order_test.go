@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// orderStub records the orders saved by the menu.
+type orderStub struct {
+	saves        int
+	hosts, group []string
+}
+
+func (s *orderStub) save(hosts, groups []string) error {
+	s.saves++
+	s.hosts, s.group = hosts, groups
+	return nil
+}
+
+func TestMoveHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		pinned     string
+		cursor     int
+		moves      []int
+		undo       bool
+		wantListed string
+		wantCursor string
+		wantStatus string
+		wantSaves  int
+	}{
+		{name: "down", cursor: 0, moves: []int{1}, wantListed: "b,a,c", wantCursor: "a", wantSaves: 1},
+		{name: "up", cursor: 2, moves: []int{-1, -1}, wantListed: "c,a,b", wantCursor: "c", wantSaves: 2},
+		{name: "top is a no-op", cursor: 0, moves: []int{-1}, wantListed: "a,b,c", wantCursor: "a"},
+		{name: "bottom is a no-op", cursor: 2, moves: []int{1}, wantListed: "a,b,c", wantCursor: "c"},
+		{
+			name: "across the pinned boundary", pinned: "a", cursor: 1, moves: []int{-1},
+			wantListed: "a,b,c", wantCursor: "b", wantStatus: "Pinned hosts stay above the others",
+		},
+		{name: "among pinned hosts", pinned: "b,c", cursor: 0, moves: []int{1}, wantListed: "c,b,a", wantCursor: "b", wantSaves: 1},
+		{name: "undo", cursor: 0, moves: []int{1, 1}, undo: true, wantListed: "b,a,c", wantCursor: "a", wantStatus: "Undid move a down", wantSaves: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts := testHosts()
+			for i := range hosts {
+				hosts[i].Pinned = slices.Contains(strings.Split(tt.pinned, ","), hosts[i].ShortName)
+			}
+			stub := &orderStub{}
+			m := newTestModel(hosts, Options{SaveOrder: stub.save})
+			m.cursor = tt.cursor
+			for _, d := range tt.moves {
+				m.moveHost(d)
+			}
+			if tt.undo {
+				m.undo()
+			}
+			if got := listed(m); got != tt.wantListed {
+				t.Errorf("expected list %s, got %s", tt.wantListed, got)
+			}
+			if got := m.currentHost().ShortName; got != tt.wantCursor {
+				t.Errorf("expected the cursor on %s, got %s", tt.wantCursor, got)
+			}
+			if m.statusMsg != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, m.statusMsg)
+			}
+			if stub.saves != tt.wantSaves {
+				t.Errorf("expected %d saves, got %d", tt.wantSaves, stub.saves)
+			}
+			if stub.saves > 0 && !slices.Equal(stub.hosts, m.hostOrder) {
+				t.Errorf("saved %v, menu has %v", stub.hosts, m.hostOrder)
+			}
+		})
+	}
+}
+
+func TestMoveHost_UndoRestoresSavedOrder(t *testing.T) {
+	order := []string{"c@f", "a@f", "b@f"}
+	hosts := testHosts()
+	// The loader applies the saved order before the menu starts.
+	host.ApplyOrder(hosts, order)
+	stub := &orderStub{}
+	m := newTestModel(hosts, Options{Order: order, SaveOrder: stub.save})
+	m.moveHost(1)
+	if got := listed(m); got != "a,c,b" {
+		t.Fatalf("expected list a,c,b, got %s", got)
+	}
+	m.undo()
+	if !slices.Equal(stub.hosts, order) {
+		t.Errorf("expected undo to save %v, got %v", order, stub.hosts)
+	}
+	if got := listed(m); got != "c,a,b" {
+		t.Errorf("expected list c,a,b, got %s", got)
+	}
+}
+
+func TestMoveHost_DuplicateAliases(t *testing.T) {
+	hosts := []host.Host{
+		{ShortName: "web", DescText: "web", MenuNumber: 1, SourceFile: "a"},
+		{ShortName: "db", DescText: "db", MenuNumber: 2, SourceFile: "a"},
+		{ShortName: "web", DescText: "web", MenuNumber: 3, SourceFile: "b"},
+	}
+	stub := &orderStub{}
+	m := newTestModel(hosts, Options{SaveOrder: stub.save})
+	m.cursor = 2
+	m.moveHost(-1)
+	if want := []string{"web@a", "web@b", "db@a"}; !slices.Equal(stub.hosts, want) {
+		t.Errorf("expected %v, got %v", want, stub.hosts)
+	}
+	if got := m.currentHost().Key(); got != "web@b" {
+		t.Errorf("expected the cursor on web@b, got %s", got)
+	}
+}
+
+func TestMoveGroup(t *testing.T) {
+	groupHosts := func() []host.Host {
+		hosts := testHosts()
+		for i, g := range []string{"G1", "G2", "G3"} {
+			hosts[i].Groups = []string{g}
+		}
+		return hosts
+	}
+	tests := []struct {
+		name       string
+		active     string
+		moves      []int
+		undo       bool
+		wantTabs   string
+		wantStatus string
+		wantSaves  int
+	}{
+		{name: "left", active: "G2", moves: []int{-1}, wantTabs: "All,G2,G1,G3", wantSaves: 1},
+		{name: "right", active: "G2", moves: []int{1}, wantTabs: "All,G1,G3,G2", wantSaves: 1},
+		{name: "first is a no-op", active: "G1", moves: []int{-1}, wantTabs: "All,G1,G2,G3"},
+		{name: "last is a no-op", active: "G3", moves: []int{1}, wantTabs: "All,G1,G2,G3"},
+		{name: "undo", active: "G2", moves: []int{-1}, undo: true, wantTabs: "All,G1,G2,G3", wantStatus: "Undid move group G2 left", wantSaves: 2},
+		{name: "not a group", active: "All", moves: []int{1}, wantTabs: "All,G1,G2,G3", wantStatus: "Only group tabs can be moved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &orderStub{}
+			m := newTestModel(groupHosts(), Options{SaveOrder: stub.save})
+			m.viewIndex = slices.Index(m.tabNames(), tt.active)
+			m.updateFilteredHosts()
+			for _, d := range tt.moves {
+				m.moveGroup(d)
+			}
+			if tt.undo {
+				m.undo()
+			}
+			if got := strings.Join(m.tabNames(), ","); got != tt.wantTabs {
+				t.Errorf("expected tabs %s, got %s", tt.wantTabs, got)
+			}
+			if got := m.tabs[m.viewIndex].name; got != tt.active {
+				t.Errorf("expected %s to stay the active tab, got %s", tt.active, got)
+			}
+			if m.statusMsg != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, m.statusMsg)
+			}
+			if stub.saves != tt.wantSaves {
+				t.Errorf("expected %d saves, got %d", tt.wantSaves, stub.saves)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/evix1101/ssh-menu/internal/theme"
+)
+
+// paletteItem is one action offered by the command palette.
+type paletteItem struct {
+	action keyAction
+	name   string
+	title  string
+	keys   string
+}
+
+// palette is the state of the open command palette.
+type palette struct {
+	query  string
+	cursor int
+	all    []paletteItem
+	items  []paletteItem
+}
+
+func (m *Model) openPalette() {
+	cur := m.currentHost()
+	var all []paletteItem
+	for _, d := range actionDefs {
+		if d.action == keyPalette || (d.needsHost && cur == nil) {
+			continue
+		}
+		var keys []string
+		for _, k := range m.keys.keys[d.action] {
+			keys = append(keys, displayKey(k))
+		}
+		all = append(all, paletteItem{
+			action: d.action,
+			name:   d.name,
+			title:  actionTitle(d, cur),
+			keys:   strings.Join(keys, ", "),
+		})
+	}
+	m.palette = &palette{all: all}
+	m.palette.filter()
+}
+
+// actionTitle describes an action in terms of the selected host where
+// that reads better than the generic description.
+func actionTitle(d actionDef, cur *host.Host) string {
+	if cur == nil {
+		return d.desc
+	}
+	switch d.action {
+	case keySelect:
+		return "Connect to " + cur.ShortName
+	case keyTogglePin:
+		if cur.Pinned {
+			return "Unpin " + cur.ShortName
+		}
+		return "Pin " + cur.ShortName
+	case keyMoveUp:
+		return "Move " + cur.ShortName + " up"
+	case keyMoveDown:
+		return "Move " + cur.ShortName + " down"
+	case keyEdit:
+		return "Edit " + cur.ShortName + " in $EDITOR"
+	case keyCopyCommand:
+		return "Copy ssh command for " + cur.ShortName
+	case keyCopyHostName:
+		return "Copy HostName of " + cur.ShortName
+	case keyCopyIP:
+		return "Copy IP address of " + cur.ShortName
+	case keyCopyUserHost:
+		return "Copy user@hostname of " + cur.ShortName
+	}
+	return d.desc
+}
+
+func (p *palette) filter() {
+	if p.query == "" {
+		p.items = p.all
+	} else {
+		type scored struct {
+			item  paletteItem
+			score int
+		}
+		var matches []scored
+		for _, it := range p.all {
+			s := host.Score(p.query, it.title)
+			if ns := host.Score(p.query, it.name); ns > s {
+				s = ns
+			}
+			if s > 0 {
+				matches = append(matches, scored{it, s})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+		p.items = make([]paletteItem, len(matches))
+		for i, sm := range matches {
+			p.items[i] = sm.item
+		}
+	}
+	if p.cursor >= len(p.items) {
+		p.cursor = len(p.items) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+func (m *Model) handlePaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.palette
+	switch msg.Type {
+	case tea.KeyEscape, tea.KeyCtrlC:
+		m.palette = nil
+	case tea.KeyEnter:
+		m.palette = nil
+		if p.cursor < len(p.items) {
+			return m.runAction(p.items[p.cursor].action)
+		}
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyCtrlK:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyCtrlJ, tea.KeyTab:
+		if p.cursor < len(p.items)-1 {
+			p.cursor++
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(p.query); len(runes) > 0 {
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	default:
+		if isText(msg) {
+			p.query += msg.String()
+			p.cursor = 0
+			p.filter()
+		}
+	}
+	return m, nil
+}
+
+func renderPalette(p *palette, width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(theme.TitleStyle().Render("Command palette"))
+	b.WriteString("\n")
+	b.WriteString(theme.SelectedStyle().Render(fmt.Sprintf("> %s▏", p.query)))
+	b.WriteString("\n\n")
+
+	if len(p.items) == 0 {
+		b.WriteString(theme.DimStyle().Render("No matching actions"))
+	}
+
+	titleWidth := 0
+	for _, it := range p.items {
+		if w := lipgloss.Width(it.title); w > titleWidth {
+			titleWidth = w
+		}
+	}
+
+	visible := height - 3
+	start := calculateScrollOffset(p.cursor, 0, visible, len(p.items))
+	for i := start; i < len(p.items) && i < start+visible; i++ {
+		it := p.items[i]
+		pointer := "  "
+		style := theme.NormalStyle()
+		if i == p.cursor {
+			pointer = "▸ "
+			style = theme.SelectedStyle()
+		}
+		b.WriteString(pointer)
+		b.WriteString(style.Width(titleWidth).Render(it.title))
+		if it.keys != "" {
+			b.WriteString("  ")
+			b.WriteString(theme.DimStyle().Render(it.keys))
+		}
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(b.String())
+}
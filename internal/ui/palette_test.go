@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// typePalette types s into the open command palette.
+func typePalette(m *Model, s string) {
+	for _, r := range s {
+		m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func actionDefFor(a keyAction) actionDef {
+	for _, d := range actionDefs {
+		if d.action == a {
+			return d
+		}
+	}
+	panic("no such action")
+}
+
+func TestPalette_Filter(t *testing.T) {
+	m := newTestModel(testHosts(), Options{})
+	m.openPalette()
+	typePalette(m, "unpin")
+	// Nothing is pinned, so the pin action is titled "Pin a".
+	if len(m.palette.items) != 0 {
+		t.Errorf("expected no match for unpin, got %v", m.palette.items)
+	}
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.palette.query != "unp" {
+		t.Fatalf("expected query unp after backspace, got %q", m.palette.query)
+	}
+
+	m.openPalette()
+	typePalette(m, "pin a")
+	if len(m.palette.items) == 0 || m.palette.items[0].action != keyTogglePin {
+		t.Fatalf("expected the pin action first, got %v", m.palette.items)
+	}
+	if got := m.palette.items[0].title; got != "Pin a" {
+		t.Errorf("expected title %q, got %q", "Pin a", got)
+	}
+}
+
+func TestPalette_HostActionsNeedAHost(t *testing.T) {
+	m := newTestModel(nil, Options{})
+	m.openPalette()
+	for _, it := range m.palette.all {
+		if actionDefFor(it.action).needsHost {
+			t.Errorf("expected no %s action without a host", it.name)
+		}
+	}
+}
+
+func TestPalette_RunsSelectedAction(t *testing.T) {
+	stub := &pinStub{pins: map[string]bool{}}
+	m := newTestModel(testHosts(), Options{Pin: stub.pin})
+	m.moveCursor(1)
+	m.openPalette()
+	typePalette(m, "pin")
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.palette != nil {
+		t.Error("expected enter to close the palette")
+	}
+	if !stub.pins["b"] {
+		t.Errorf("expected b pinned, got %v", stub.pins)
+	}
+
+	m.openPalette()
+	typePalette(m, "zzz")
+	m.handlePaletteKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.palette != nil || len(stub.pins) != 1 {
+		t.Errorf("expected enter on no match to only close the palette, got pins %v", stub.pins)
+	}
+}
+
+func TestActionTitle(t *testing.T) {
+	web := &host.Host{ShortName: "web"}
+	pinned := &host.Host{ShortName: "web", Pinned: true}
+	tests := []struct {
+		action keyAction
+		cur    *host.Host
+		want   string
+	}{
+		{keySelect, web, "Connect to web"},
+		{keySelect, nil, actionDefFor(keySelect).desc},
+		{keyTogglePin, web, "Pin web"},
+		{keyTogglePin, pinned, "Unpin web"},
+		{keyMoveDown, web, "Move web down"},
+		{keyCopyIP, web, "Copy IP address of web"},
+		{keyHelp, web, actionDefFor(keyHelp).desc},
+	}
+	for _, tt := range tests {
+		d := actionDefFor(tt.action)
+		if got := actionTitle(d, tt.cur); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", d.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+const (
+	probeTimeout   = 2 * time.Second
+	probeBatchSize = 32
+	// probeRefreshInterval is how often the list is refiltered while
+	// probe results come in, so large inventories are not re-indexed
+	// after every batch.
+	probeRefreshInterval = 500 * time.Millisecond
+)
+
+// probeMsg carries reachability results for a batch of hosts, keyed by
+// host.Key, along with the hosts still waiting to be probed. gen is the
+// probe run the batch belongs to.
+type probeMsg struct {
+	gen       int
+	results   map[string]host.Reachability
+	remaining []host.Host
+}
+
+// probeRefreshMsg asks for the list to be refiltered with the probe
+// results received since the last refresh.
+type probeRefreshMsg struct{}
+
+// startProbes begins probing every host in the background the first time
+// the active view or filter depends on reachability.
+func (m *Model) startProbes() tea.Cmd {
+	if m.probing || !m.needsReachability() {
+		return nil
+	}
+	m.probing = true
+	pending := make([]host.Host, len(m.hosts))
+	copy(pending, m.hosts)
+	return probeBatch(m.probeGen, pending)
+}
+
+// restartProbes probes the hosts without a result after a reload. The
+// batches still in flight were taken from the old hosts, so the hosts
+// they had left are dropped.
+func (m *Model) restartProbes() tea.Cmd {
+	if !m.probing {
+		return nil
+	}
+	m.probeGen++
+	return probeBatch(m.probeGen, m.unprobed())
+}
+
+// unprobed returns the hosts that have no probe result yet.
+func (m *Model) unprobed() []host.Host {
+	var pending []host.Host
+	for _, h := range m.hosts {
+		if h.Reachable == host.ReachUnknown {
+			pending = append(pending, h)
+		}
+	}
+	return pending
+}
+
+func (m *Model) needsReachability() bool {
+	if hasColumn(m.columns, "reach") {
+		return true
+	}
+	// Only the is: predicate needs probes, so tag keys do not matter.
+	if host.ParseQuery(m.filterText, nil).NeedsReachability() {
+		return true
+	}
+	return m.viewIndex < len(m.tabs) && host.ParseQuery(m.tabs[m.viewIndex].query, nil).NeedsReachability()
+}
+
+// applyProbes records a batch of results and probes the next batch. The
+// list is refreshed at most once per probeRefreshInterval.
+func (m *Model) applyProbes(msg probeMsg) tea.Cmd {
+	for i := range m.hosts {
+		if r, ok := msg.results[m.hosts[i].Key()]; ok {
+			m.hosts[i].Reachable = r
+		}
+	}
+	var cmds []tea.Cmd
+	if !m.probeRefresh {
+		m.probeRefresh = true
+		cmds = append(cmds, tea.Tick(probeRefreshInterval, func(time.Time) tea.Msg { return probeRefreshMsg{} }))
+	}
+	if msg.gen == m.probeGen {
+		cmds = append(cmds, probeBatch(msg.gen, msg.remaining))
+	}
+	return tea.Batch(cmds...)
+}
+
+// refreshProbes refilters the list with the probe results received since
+// the last refresh.
+func (m *Model) refreshProbes() tea.Cmd {
+	m.probeRefresh = false
+	m.invalidateIndex()
+	return m.updateFilteredHosts()
+}
+
+// probeBatch probes up to probeBatchSize hosts concurrently.
+func probeBatch(gen int, hosts []host.Host) tea.Cmd {
+	if len(hosts) == 0 {
+		return nil
+	}
+	n := probeBatchSize
+	if n > len(hosts) {
+		n = len(hosts)
+	}
+	batch, rest := hosts[:n], hosts[n:]
+	return func() tea.Msg {
+		results := make(map[string]host.Reachability, len(batch))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, h := range batch {
+			wg.Add(1)
+			go func(h host.Host) {
+				defer wg.Done()
+				r := host.Probe(h, probeTimeout)
+				mu.Lock()
+				results[h.Key()] = r
+				mu.Unlock()
+			}(h)
+		}
+		wg.Wait()
+		return probeMsg{gen: gen, results: results, remaining: rest}
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func TestApplyProbes_CoalescesRefreshes(t *testing.T) {
+	m := newTestModel(testHosts(), Options{})
+	index := m.index
+	m.applyProbes(probeMsg{results: map[string]host.Reachability{"a@f": host.ReachOK}})
+	m.applyProbes(probeMsg{results: map[string]host.Reachability{"b@f": host.ReachFailed}})
+	if !m.probeRefresh {
+		t.Fatal("expected a refresh to be scheduled")
+	}
+	if m.index != index {
+		t.Error("expected the index to be kept until the refresh")
+	}
+	if m.hosts[0].Reachable != host.ReachOK || m.hosts[1].Reachable != host.ReachFailed {
+		t.Errorf("expected the results on the hosts, got %v and %v", m.hosts[0].Reachable, m.hosts[1].Reachable)
+	}
+
+	m.refreshProbes()
+	if m.probeRefresh {
+		t.Error("expected the next batch to schedule a new refresh")
+	}
+	want := map[string]host.Reachability{"a": host.ReachOK, "b": host.ReachFailed, "c": host.ReachUnknown}
+	for _, r := range m.filtered {
+		if r.Host.Reachable != want[r.Host.ShortName] {
+			t.Errorf("%s: expected %v in the list, got %v", r.Host.ShortName, want[r.Host.ShortName], r.Host.Reachable)
+		}
+	}
+}
+
+func TestApplyReload_RestartsProbes(t *testing.T) {
+	m := newTestModel(testHosts(), Options{Columns: []string{"reach"}})
+	if m.startProbes() == nil || !m.probing {
+		t.Fatal("expected probing to start for the reach column")
+	}
+	m.applyProbes(probeMsg{results: map[string]host.Reachability{"a@f": host.ReachOK}})
+
+	hosts := append(testHosts(), host.Host{ShortName: "d", DescText: "d", MenuNumber: 4, SourceFile: "f"})
+	if m.applyReload(reloadMsg{hosts: hosts}) == nil {
+		t.Fatal("expected a command probing the new hosts")
+	}
+	var names []string
+	for _, h := range m.unprobed() {
+		names = append(names, h.ShortName)
+	}
+	if got := strings.Join(names, ","); got != "b,c,d" {
+		t.Errorf("expected b, c and d to be probed, got %s", got)
+	}
+
+	// A batch from before the reload still reports its results but does
+	// not probe the old hosts it had left.
+	m.probeRefresh = true
+	stale := probeMsg{gen: m.probeGen - 1, results: map[string]host.Reachability{"b@f": host.ReachFailed}, remaining: testHosts()}
+	if cmd := m.applyProbes(stale); cmd != nil {
+		t.Error("expected the stale batch to stop")
+	}
+	if m.hosts[1].Reachable != host.ReachFailed {
+		t.Errorf("expected b's result to be kept, got %v", m.hosts[1].Reachable)
+	}
+	current := probeMsg{gen: m.probeGen, remaining: hosts[2:]}
+	if cmd := m.applyProbes(current); cmd == nil {
+		t.Error("expected the current batch to go on")
+	}
+}
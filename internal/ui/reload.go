@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// configChangedMsg reports that the SSH config changed on disk.
+type configChangedMsg struct{}
+
+// reloadMsg carries freshly loaded hosts, or the error loading them.
+type reloadMsg struct {
+	hosts []host.Host
+	err   error
+}
+
+// waitForChange waits for the next value on changes.
+func waitForChange(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return configChangedMsg{}
+	}
+}
+
+// reloadHosts runs the reload pipeline in the background.
+func (m *Model) reloadHosts() tea.Cmd {
+	if m.reload == nil {
+		return nil
+	}
+	reload := m.reload
+	return func() tea.Msg {
+		hosts, err := reload()
+		return reloadMsg{hosts: hosts, err: err}
+	}
+}
+
+// applyReload replaces the hosts with a reloaded set, keeping the active
+// view, the filter and the cursor on the same host where they still exist.
+// Reachability already probed is carried over to hosts that remain, and
+// new hosts are probed if probing has started.
+func (m *Model) applyReload(msg reloadMsg) tea.Cmd {
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Reload failed: %v", msg.err)
+		return nil
+	}
+
+	reach := make(map[string]host.Reachability, len(m.hosts))
+	for _, h := range m.hosts {
+		reach[h.Key()] = h.Reachable
+	}
+	for i := range msg.hosts {
+		msg.hosts[i].Reachable = reach[msg.hosts[i].Key()]
+	}
+
+	if h := m.currentHost(); h != nil {
+		m.keepCursor = h.Key()
+	}
+	activeTab := ""
+	if m.viewIndex < len(m.tabs) {
+		activeTab = m.tabs[m.viewIndex].name
+	}
+
+	m.hosts = msg.hosts
+	m.tabs = buildTabs(m.views, m.groups())
+	m.viewIndex = 0
+	for i, t := range m.tabs {
+		if strings.EqualFold(t.name, activeTab) {
+			m.viewIndex = i
+		}
+	}
+	// A reload following an undo or redo keeps its message.
+	if m.statusMsg == "" {
+		m.statusMsg = "Config reloaded"
+	}
+	m.invalidateIndex()
+	return tea.Batch(m.updateFilteredHosts(), m.restartProbes())
+}
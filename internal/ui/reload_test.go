@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+func TestApplyReload_KeepsPlace(t *testing.T) {
+	grouped := func(name string, number int, group string) host.Host {
+		return host.Host{ShortName: name, DescText: name, MenuNumber: number, SourceFile: "f", Groups: []string{group}}
+	}
+	m := newTestModel([]host.Host{
+		grouped("web-a", 1, "G"),
+		grouped("web-b", 2, "G"),
+		grouped("db-c", 3, "G"),
+		grouped("web-x", 4, "H"),
+	}, Options{})
+	for i, tb := range m.tabs {
+		if tb.name == "G" {
+			m.viewIndex = i
+		}
+	}
+	m.setFilter("web")
+	m.moveCursor(1)
+	if got := m.currentHost().ShortName; got != "web-b" {
+		t.Fatalf("expected the cursor on web-b, got %s", got)
+	}
+	m.hosts[1].Reachable = host.ReachOK
+
+	// The reload adds a host ahead of the cursor and a group ahead of the
+	// active tab, and drops db-c.
+	m.applyReload(reloadMsg{hosts: []host.Host{
+		grouped("web-0", 1, "G"),
+		grouped("web-a", 2, "G"),
+		grouped("web-b", 3, "G"),
+		grouped("web-x", 4, "A"),
+	}})
+	if got := m.tabs[m.viewIndex].name; got != "G" {
+		t.Errorf("expected tab G to stay active, got %s", got)
+	}
+	if m.filterText != "web" {
+		t.Errorf("expected the filter kept, got %q", m.filterText)
+	}
+	if got := listed(m); got != "web-0,web-a,web-b" {
+		t.Errorf("expected list web-0,web-a,web-b, got %s", got)
+	}
+	cur := m.currentHost()
+	if cur == nil || cur.ShortName != "web-b" {
+		t.Fatalf("expected the cursor to stay on web-b, got %v", cur)
+	}
+	if cur.Reachable != host.ReachOK {
+		t.Errorf("expected web-b to stay reachable, got %v", cur.Reachable)
+	}
+	if r := m.filtered[0].Host.Reachable; r != host.ReachUnknown {
+		t.Errorf("expected the new host unprobed, got %v", r)
+	}
+	if m.statusMsg != "Config reloaded" {
+		t.Errorf("unexpected status %q", m.statusMsg)
+	}
+}
+
+func TestApplyReload_Error(t *testing.T) {
+	m := newTestModel(testHosts(), Options{})
+	m.applyReload(reloadMsg{err: errors.New("bad config")})
+	if got := listed(m); got != "a,b,c" {
+		t.Errorf("expected the hosts kept, got %s", got)
+	}
+	if m.statusMsg != "Reload failed: bad config" {
+		t.Errorf("unexpected status %q", m.statusMsg)
+	}
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// operation is a change written to disk from the menu, such as a pin or
+// an edit in $EDITOR, kept so it can be undone and redone.
+type operation struct {
+	// desc names the change in the status line, e.g. "pin web-01".
+	desc string
+	undo func() (tea.Cmd, error)
+	redo func() (tea.Cmd, error)
+}
+
+// record adds an operation that has just been applied to the undo stack.
+// A new change discards the operations that were undone before it.
+func (m *Model) record(op operation) {
+	m.undoStack = append(m.undoStack, op)
+	m.redoStack = nil
+}
+
+// undo reverts the most recent operation and moves it to the redo stack.
+func (m *Model) undo() tea.Cmd {
+	return m.replay(&m.undoStack, &m.redoStack, "undo", "Undid")
+}
+
+// redo re-applies the most recently undone operation.
+func (m *Model) redo() tea.Cmd {
+	return m.replay(&m.redoStack, &m.undoStack, "redo", "Redid")
+}
+
+// replay pops an operation from one stack, applies its undo or redo and
+// pushes it onto the other. An operation that fails stays where it was,
+// so it can be retried once the cause is fixed.
+func (m *Model) replay(from, to *[]operation, verb, done string) tea.Cmd {
+	if len(*from) == 0 {
+		m.statusMsg = "Nothing to " + verb
+		return nil
+	}
+	op := (*from)[len(*from)-1]
+	apply := op.redo
+	if verb == "undo" {
+		apply = op.undo
+	}
+	cmd, err := apply()
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Could not %s %s: %v", verb, op.desc, err)
+		return nil
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, op)
+	m.statusMsg = fmt.Sprintf("%s %s", done, op.desc)
+	return cmd
+}
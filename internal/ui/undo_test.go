@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+)
+
+// testHosts returns hosts a, b and c, numbered in that order and all in
+// file f.
+func testHosts() []host.Host {
+	var hosts []host.Host
+	for i, name := range []string{"a", "b", "c"} {
+		hosts = append(hosts, host.Host{ShortName: name, DescText: name, MenuNumber: i + 1, SourceFile: "f"})
+	}
+	return hosts
+}
+
+// newTestModel builds a model with the menu's filter already applied.
+func newTestModel(hosts []host.Host, opts Options) *Model {
+	m := New(hosts, opts)
+	m.updateFilteredHosts()
+	return m
+}
+
+// listed returns the aliases of the hosts in the list, in order.
+func listed(m *Model) string {
+	var names []string
+	for _, r := range m.filtered {
+		names = append(names, r.Host.ShortName)
+	}
+	return strings.Join(names, ",")
+}
+
+// pinStub records the pins saved by the menu, failing while fail is set.
+type pinStub struct {
+	pins map[string]bool
+	fail bool
+}
+
+func (s *pinStub) pin(h host.Host, pinned bool) error {
+	if s.fail {
+		return errors.New("read-only")
+	}
+	s.pins[h.ShortName] = pinned
+	return nil
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps are run in order: "pin" toggles the pin of the host under
+		// the cursor, "undo" and "redo" replay the stacks and "fail" and
+		// "ok" make saving pins fail or succeed.
+		steps      []string
+		wantListed string
+		wantPins   map[string]bool
+		wantStatus string
+		wantUndo   int
+		wantRedo   int
+	}{
+		{
+			name:       "pin",
+			steps:      []string{"pin"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{"a": true},
+			wantUndo:   1,
+		},
+		{
+			name:       "undo",
+			steps:      []string{"pin", "undo"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{"a": false},
+			wantStatus: "Undid pin a",
+			wantRedo:   1,
+		},
+		{
+			name:       "undo then redo",
+			steps:      []string{"pin", "undo", "redo"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{"a": true},
+			wantStatus: "Redid pin a",
+			wantUndo:   1,
+		},
+		{
+			name:       "failed undo stays on the stack",
+			steps:      []string{"pin", "fail", "undo"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{"a": true},
+			wantStatus: "Could not undo pin a: read-only",
+			wantUndo:   1,
+		},
+		{
+			name:       "failed undo can be retried",
+			steps:      []string{"pin", "fail", "undo", "ok", "undo"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{"a": false},
+			wantStatus: "Undid pin a",
+			wantRedo:   1,
+		},
+		{
+			name:       "new change clears redo",
+			steps:      []string{"pin", "undo", "down", "pin", "redo"},
+			wantListed: "b,a,c",
+			wantPins:   map[string]bool{"a": false, "b": true},
+			wantStatus: "Nothing to redo",
+			wantUndo:   1,
+		},
+		{
+			name:       "nothing to undo",
+			steps:      []string{"undo"},
+			wantListed: "a,b,c",
+			wantPins:   map[string]bool{},
+			wantStatus: "Nothing to undo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &pinStub{pins: map[string]bool{}}
+			m := newTestModel(testHosts(), Options{Pin: stub.pin})
+			for _, step := range tt.steps {
+				switch step {
+				case "pin":
+					m.togglePin()
+				case "undo":
+					m.undo()
+				case "redo":
+					m.redo()
+				case "down":
+					m.moveCursor(1)
+				case "fail", "ok":
+					stub.fail = step == "fail"
+				}
+			}
+			if got := listed(m); got != tt.wantListed {
+				t.Errorf("expected list %s, got %s", tt.wantListed, got)
+			}
+			for alias, want := range tt.wantPins {
+				if got := stub.pins[alias]; got != want {
+					t.Errorf("%s: expected pinned %v, got %v", alias, want, got)
+				}
+			}
+			for _, h := range m.hosts {
+				if h.Pinned != stub.pins[h.ShortName] {
+					t.Errorf("%s: menu shows pinned %v, saved %v", h.ShortName, h.Pinned, stub.pins[h.ShortName])
+				}
+			}
+			if m.statusMsg != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, m.statusMsg)
+			}
+			if len(m.undoStack) != tt.wantUndo || len(m.redoStack) != tt.wantRedo {
+				t.Errorf("expected %d undo and %d redo, got %d and %d",
+					tt.wantUndo, tt.wantRedo, len(m.undoStack), len(m.redoStack))
+			}
+		})
+	}
+}
+
+func TestEditorDone(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tests := []struct {
+		name, before, after string
+		wantUndo            int
+	}{
+		{"changed", "Host a\n", "Host b\n", 1},
+		{"unchanged", "Host a\n", "Host a\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.after), 0600); err != nil {
+				t.Fatal(err)
+			}
+			m := newTestModel(testHosts(), Options{})
+			m.editorDone(editorDoneMsg{path: path, before: []byte(tt.before)})
+			if len(m.undoStack) != tt.wantUndo {
+				t.Fatalf("expected %d recorded edits, got %d", tt.wantUndo, len(m.undoStack))
+			}
+			if tt.wantUndo == 0 {
+				return
+			}
+			m.undo()
+			if got, _ := os.ReadFile(path); string(got) != tt.before {
+				t.Errorf("expected undo to restore %q, got %q", tt.before, got)
+			}
+			if m.statusMsg != "Undid edit of config" {
+				t.Errorf("unexpected status %q", m.statusMsg)
+			}
+			m.redo()
+			if got, _ := os.ReadFile(path); string(got) != tt.after {
+				t.Errorf("expected redo to write %q, got %q", tt.after, got)
+			}
+		})
+	}
+}
+
+func TestEditorDone_FailedEditorNotRecorded(t *testing.T) {
+	m := newTestModel(testHosts(), Options{})
+	m.editorDone(editorDoneMsg{err: errors.New("exit status 1"), path: "config", before: []byte("x")})
+	if len(m.undoStack) != 0 {
+		t.Errorf("expected nothing recorded, got %d", len(m.undoStack))
+	}
+}
@@ -3,35 +3,105 @@ package ui
 import (
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+
 	"github.com/evix1101/ssh-menu/internal/theme"
 )
 
-func renderViewBar(groups []string, activeIndex int) string {
-	totalViews := 1 + len(groups)
-	tabs := make([]string, totalViews)
+const (
+	viewBarSeparator = " • "
+	// viewBarMoreLeft and viewBarMoreRight mark tabs scrolled out of a
+	// view bar too wide for the terminal.
+	viewBarMoreLeft  = "‹ "
+	viewBarMoreRight = " ›"
+)
+
+// barTab is a tab shown in the view bar: its index in the tab list and
+// the columns it covers.
+type barTab struct {
+	index, x, width int
+}
+
+// layoutViewBar places the tabs of the view bar in width columns. When
+// they do not all fit, the bar scrolls so the active tab is shown, and
+// markers show that tabs are hidden on either side. A width of 0 or less
+// shows every tab.
+func layoutViewBar(names []string, activeIndex, width int) []barTab {
+	widths := make([]int, len(names))
+	for i, name := range names {
+		// Both tab styles pad the label by one column on each side.
+		widths[i] = lipgloss.Width(tabLabel(name)) + 2
+	}
+	sepWidth := lipgloss.Width(viewBarSeparator)
+	rightWidth := lipgloss.Width(viewBarMoreRight)
+
+	var tabs []barTab
+	for first := 0; first < len(names); first++ {
+		tabs = tabs[:0]
+		x := 0
+		if first > 0 {
+			x = lipgloss.Width(viewBarMoreLeft)
+		}
+		for i := first; i < len(names); i++ {
+			limit := width
+			if i < len(names)-1 {
+				limit -= rightWidth
+			}
+			if width > 0 && x+widths[i] > limit && len(tabs) > 0 {
+				break
+			}
+			tabs = append(tabs, barTab{index: i, x: x, width: widths[i]})
+			x += widths[i] + sepWidth
+		}
+		if first >= activeIndex || tabs[len(tabs)-1].index >= activeIndex {
+			break
+		}
+	}
+	return tabs
+}
 
+// renderViewBar draws the view bar laid out by layoutViewBar.
+func renderViewBar(names []string, activeIndex, width int) string {
 	activeStyle := theme.ActiveTabStyle()
 	inactiveStyle := theme.InactiveTabStyle()
+	dimStyle := theme.DimStyle()
 
-	if activeIndex == 0 {
-		tabs[0] = activeStyle.Render("All")
-	} else {
-		tabs[0] = inactiveStyle.Render("All")
+	tabs := layoutViewBar(names, activeIndex, width)
+	var b strings.Builder
+	if tabs[0].index > 0 {
+		b.WriteString(dimStyle.Render(viewBarMoreLeft))
 	}
-
-	for i, group := range groups {
-		displayName := group
-		runes := []rune(group)
-		if len(runes) > 12 {
-			displayName = string(runes[:12]) + "…"
+	for n, t := range tabs {
+		if n > 0 {
+			b.WriteString(dimStyle.Render(viewBarSeparator))
 		}
-		if activeIndex == i+1 {
-			tabs[i+1] = activeStyle.Render(displayName)
-		} else {
-			tabs[i+1] = inactiveStyle.Render(displayName)
+		style := inactiveStyle
+		if t.index == activeIndex {
+			style = activeStyle
 		}
+		b.WriteString(style.Render(tabLabel(names[t.index])))
+	}
+	if tabs[len(tabs)-1].index < len(names)-1 {
+		b.WriteString(dimStyle.Render(viewBarMoreRight))
+	}
+	return b.String()
+}
+
+func tabLabel(name string) string {
+	runes := []rune(name)
+	if len(runes) > 12 {
+		return string(runes[:12]) + "…"
 	}
+	return name
+}
 
-	separator := theme.DimStyle().Render(" • ")
-	return strings.Join(tabs, separator)
+// viewBarTabAt returns the index of the tab rendered at column x by
+// renderViewBar, or -1 if x falls outside every tab.
+func viewBarTabAt(names []string, activeIndex, width, x int) int {
+	for _, t := range layoutViewBar(names, activeIndex, width) {
+		if x >= t.x && x < t.x+t.width {
+			return t.index
+		}
+	}
+	return -1
 }
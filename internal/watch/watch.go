@@ -0,0 +1,131 @@
+// Package watch reports changes to SSH config files on disk.
+package watch
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce is how long the watcher waits for further events before
+// reporting a change, so a save that touches several files, or an editor
+// writing a file in stages, is reported once.
+const debounce = 200 * time.Millisecond
+
+// Watcher reports changes to a set of files and directories. The parent
+// directories of watched files are watched rather than the files
+// themselves, so editors that save by renaming a new file over the old one
+// are still noticed.
+type Watcher struct {
+	fs      *fsnotify.Watcher
+	changes chan struct{}
+
+	mu      sync.Mutex
+	files   map[string]bool
+	dirs    map[string]bool
+	watched map[string]bool
+}
+
+// New starts a watcher with nothing to watch; call Set to choose paths.
+func New() (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:      fs,
+		changes: make(chan struct{}, 1),
+		watched: make(map[string]bool),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Set replaces the watched paths: files are individual files, and any
+// change to an entry of one of dirs counts. Paths that do not exist yet
+// are picked up once their parent directory is being watched.
+func (w *Watcher) Set(files, dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files = make(map[string]bool, len(files))
+	w.dirs = make(map[string]bool, len(dirs))
+	need := make(map[string]bool)
+	for _, f := range files {
+		f = filepath.Clean(f)
+		w.files[f] = true
+		need[filepath.Dir(f)] = true
+	}
+	for _, d := range dirs {
+		d = filepath.Clean(d)
+		w.dirs[d] = true
+		need[d] = true
+		need[filepath.Dir(d)] = true
+	}
+
+	for d := range w.watched {
+		if !need[d] {
+			_ = w.fs.Remove(d)
+			delete(w.watched, d)
+		}
+	}
+	for d := range need {
+		if !w.watched[d] && w.fs.Add(d) == nil {
+			w.watched[d] = true
+		}
+	}
+}
+
+// Changes returns a channel that receives a value after watched paths
+// change. Changes that happen before the last one was received are merged.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops the watcher and closes the Changes channel.
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+func (w *Watcher) relevant(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	name = filepath.Clean(name)
+	return w.files[name] || w.dirs[name] || w.dirs[filepath.Dir(name)]
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod || !w.relevant(ev.Name) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(debounce)
+			} else {
+				timer.Reset(debounce)
+			}
+			fire = timer.C
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		case <-fire:
+			fire = nil
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func expectChange(t *testing.T, w *Watcher, want bool) {
+	t.Helper()
+	select {
+	case <-w.Changes():
+		if !want {
+			t.Error("unexpected change reported")
+		}
+	case <-time.After(5 * debounce):
+		if want {
+			t.Error("expected a change to be reported")
+		}
+	}
+}
+
+func newWatcher(t *testing.T, files, dirs []string) *Watcher {
+	t.Helper()
+	w, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	w.Set(files, dirs)
+	return w
+}
+
+func TestWatcher_FileWrite(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte("Host a\n"), 0644)
+	w := newWatcher(t, []string{config}, nil)
+
+	os.WriteFile(config, []byte("Host b\n"), 0644)
+	expectChange(t, w, true)
+}
+
+func TestWatcher_RenameOverFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte("Host a\n"), 0644)
+	w := newWatcher(t, []string{config}, nil)
+
+	tmp := filepath.Join(dir, ".config.swp")
+	os.WriteFile(tmp, []byte("Host b\n"), 0644)
+	os.Rename(tmp, config)
+	expectChange(t, w, true)
+}
+
+func TestWatcher_IgnoresUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte("Host a\n"), 0644)
+	w := newWatcher(t, []string{config}, nil)
+
+	os.WriteFile(filepath.Join(dir, "known_hosts"), []byte("x\n"), 0644)
+	expectChange(t, w, false)
+}
+
+func TestWatcher_NewFileInDir(t *testing.T) {
+	dir := t.TempDir()
+	configD := filepath.Join(dir, "config.d")
+	os.Mkdir(configD, 0755)
+	w := newWatcher(t, nil, []string{configD})
+
+	os.WriteFile(filepath.Join(configD, "new"), []byte("Host a\n"), 0644)
+	expectChange(t, w, true)
+}
+
+func TestWatcher_SetDropsOldPaths(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old")
+	other := t.TempDir()
+	current := filepath.Join(other, "current")
+	os.WriteFile(old, []byte("Host a\n"), 0644)
+	os.WriteFile(current, []byte("Host a\n"), 0644)
+	w := newWatcher(t, []string{old}, nil)
+	w.Set([]string{current}, nil)
+
+	os.WriteFile(old, []byte("Host b\n"), 0644)
+	expectChange(t, w, false)
+	os.WriteFile(current, []byte("Host b\n"), 0644)
+	expectChange(t, w, true)
+}
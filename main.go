@@ -1,87 +1,522 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/evix1101/ssh-menu/internal/config"
 	"github.com/evix1101/ssh-menu/internal/host"
-	"github.com/evix1101/ssh-menu/internal/theme"
-	"github.com/evix1101/ssh-menu/internal/ui"
+	"github.com/evix1101/ssh-menu/internal/state"
 )
 
-func main() {
-	verbosePtr := flag.Bool("V", false, "Enable SSH verbose mode (-v flag)")
-	groupPtr := flag.String("g", "", "Filter hosts by group")
-	listGroupsPtr := flag.Bool("l", false, "List all available groups")
-	sshOptsPtr := flag.String("s", "", "Additional SSH options to pass through")
-	flag.Parse()
+// Exit codes shared by every command. Commands that run ssh or another
+// program exit with that program's status when it fails.
+const (
+	exitOK    = 0
+	exitError = 1 // the command failed, e.g. a host was not found
+	exitUsage = 2 // invalid flags or arguments
+)
 
-	configPath := sshConfigPath()
+// argKind says what a command's first argument is, for completion.
+type argKind int
+
+const (
+	argNone argKind = iota
+	argHost
+	argShell
+	argGroupAction
+)
+
+// command is an ssh-menu subcommand.
+type command struct {
+	name    string
+	aliases []string
+	args    string // argument synopsis shown in usage, e.g. "<host>"
+	summary string
+	arg     argKind
+	hidden  bool
+	// rawArgs passes every argument to the command without parsing flags.
+	rawArgs bool
+	// interspersed also parses flags that follow the arguments, as in
+	// "pin web --dry-run". Arguments after "--" are never flags.
+	interspersed bool
+	// setup defines the command's flags on fs and returns the function
+	// running the command with the arguments left after the flags.
+	setup func(fs *flag.FlagSet) func(args []string) int
+}
+
+// commands returns every subcommand in the order shown by help.
+func commands() []command {
+	return []command{
+		{name: "connect", args: "[host]", arg: argHost, setup: connectCommand,
+			summary: "Connect to a host by menu number or alias, or pick one from the menu"},
+		{name: "list", setup: listCommand,
+			summary: "List menu hosts"},
+		{name: "groups", setup: groupsCommand,
+			summary: "List groups and saved views"},
+		{name: "pin", args: "<host>", arg: argHost, setup: pinCommand(true), interspersed: true,
+			summary: "Pin a host to the top of the menu"},
+		{name: "unpin", args: "<host>", arg: argHost, setup: pinCommand(false), interspersed: true,
+			summary: "Unpin a host"},
+		{name: "group", args: "add|remove <host> <group>", arg: argGroupAction, setup: groupCommand, interspersed: true,
+			summary: "Add a host to a group or remove it from one"},
+		{name: "describe", args: "<host> <description>...", arg: argHost, setup: describeCommand, interspersed: true,
+			summary: "Change a host's menu description"},
+		{name: "exec", args: "[host] [--] <command>...", arg: argHost, setup: execCommand,
+			summary: "Run a command on a host, or on every host selected with -g, -q or --view"},
+		{name: "export", setup: exportCommand,
+			summary: "Print menu hosts as JSON or CSV"},
+		{name: "edit", args: "<host>", arg: argHost, setup: editCommand,
+			summary: "Open a host's config in $VISUAL or $EDITOR"},
+		{name: "lint", aliases: []string{"doctor"}, setup: lintCommand,
+			summary: "Check the SSH config files for problems"},
+		{name: "renumber", setup: renumberCommand,
+			summary: "Write auto-assigned menu numbers into the config as # Menu N: comments"},
+		{name: "completion", args: "bash|zsh|fish", arg: argShell, setup: completionCommand,
+			summary: "Print a shell completion script"},
+		{name: "__complete", hidden: true, rawArgs: true, setup: completeCommand},
+	}
+}
 
-	theme.Init(configPath)
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+		for _, a := range c.aliases {
+			if a == name {
+				return c, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+// rootCmd is the bare "ssh-menu" command.
+var rootCmd = command{args: "[host]", arg: argHost, setup: rootCommand}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	hosts, err := config.ReadConfigFiles(configPath)
+// run dispatches to a subcommand. Anything else, such as a bare
+// "ssh-menu", "ssh-menu 3" or "ssh-menu -g Prod", is short for connect.
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			return help(args[1:])
+		}
+		if c, ok := findCommand(args[0]); ok {
+			return c.run(args[1:])
+		}
+	}
+	return rootCmd.run(args)
+}
+
+// run parses the command's flags and runs it.
+func (c command) run(args []string) int {
+	fs := c.flagSet()
+	runFn := c.setup(fs)
+	if c.rawArgs {
+		return runFn(args)
+	}
+	parse := func(args []string) ([]string, error) {
+		err := fs.Parse(args)
+		return fs.Args(), err
+	}
+	if c.interspersed {
+		parse = func(args []string) ([]string, error) { return parseInterspersed(fs, args) }
+	}
+	args, err := parse(args)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading SSH config: %s\n", err)
-		os.Exit(1)
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	return runFn(args)
+}
+
+// parseInterspersed parses the flags in args wherever they appear and
+// returns the other arguments, keeping everything after "--" as is.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		left := fs.Args()
+		if n := len(args) - len(left); n > 0 && args[n-1] == "--" {
+			return append(rest, left...), nil
+		}
+		if len(left) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, left[0])
+		args = left[1:]
+	}
+}
+
+// flagSet returns an empty flag set whose usage describes the command.
+func (c command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("ssh-menu "+c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		if c.name == "" {
+			printUsage(w)
+			fmt.Fprintln(w, "\nFlags for connecting:")
+			fs.PrintDefaults()
+			return
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		synopsis := "ssh-menu " + c.name
+		if hasFlags {
+			synopsis += " [flags]"
+		}
+		fmt.Fprintf(w, "Usage: %s\n\n%s\n", strings.TrimSpace(synopsis+" "+c.args), c.summary)
+		if len(c.aliases) > 0 {
+			fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+		}
+		if hasFlags {
+			fmt.Fprintln(w, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage: ssh-menu [flags] [host]
+       ssh-menu <command> [flags] [args]
+
+Without a command, ssh-menu connects to the given host by menu number or
+alias, or opens the menu; it is short for "ssh-menu connect".
+
+Commands:
+`)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands() {
+		if !c.hidden {
+			fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+		}
 	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nRun 'ssh-menu help <command>' for a command's flags.")
+}
+
+// help prints general usage, or the usage of the command named in args.
+func help(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	c, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown command '%s'\n", args[0])
+		return exitUsage
+	}
+	fs := c.flagSet()
+	fs.SetOutput(os.Stdout)
+	c.setup(fs)
+	fs.Usage()
+	return exitOK
+}
+
+// rootCommand is the bare "ssh-menu" command: connect, plus the older -l
+// flag listing groups.
+func rootCommand(fs *flag.FlagSet) func([]string) int {
+	listGroups := fs.Bool("l", false, "List all available groups (same as 'ssh-menu groups')")
+	o := addConnectFlags(fs)
+	return func(args []string) int {
+		if *listGroups {
+			return runGroups(o.load)
+		}
+		return o.run(fs, args)
+	}
+}
 
+// usageError reports a problem with a command's arguments.
+func usageError(fs *flag.FlagSet, format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n\n", args...)
+	fs.Usage()
+	return exitUsage
+}
+
+// loadFlags are the flags of every command that reads hosts.
+type loadFlags struct {
+	noCache       *bool
+	stableNumbers *bool
+	strict        *bool
+}
+
+func addLoadFlags(fs *flag.FlagSet) *loadFlags {
+	return &loadFlags{
+		noCache:       fs.Bool("no-cache", false, "Parse every SSH config file instead of using the parse cache"),
+		stableNumbers: fs.Bool("stable-numbers", false, "Keep auto-assigned menu numbers across runs"),
+		strict:        fs.Bool("strict", false, "Fail on duplicate menu numbers instead of renumbering with a warning"),
+	}
+}
+
+// env is what a command needs to read hosts: the config location, its
+// ssh-menu settings and the per-user state.
+type env struct {
+	configPath string
+	settings   config.Settings
+	st         *state.State
+	statePath  string
+	loader     *hostLoader
+}
+
+// newEnv reads the settings and state and prepares a host loader.
+func (f *loadFlags) newEnv() *env {
+	configPath := sshConfigPath()
+	e := &env{
+		configPath: configPath,
+		settings:   config.ReadSettings(configPath),
+		statePath:  state.DefaultPath(),
+	}
+	switch e.settings.PinStore {
+	case "", config.PinStoreConfig, config.PinStoreState:
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: unknown PinStore '%s'; pins are saved in the SSH config\n", e.settings.PinStore)
+	}
+	var err error
+	if e.st, err = state.Load(e.statePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		e.st = &state.State{}
+	}
+	var cache *config.Cache
+	if dir := config.DefaultCacheDir(); dir != "" && !*f.noCache {
+		cache = config.NewCache(dir)
+	}
+	e.loader = &hostLoader{
+		configPath:    configPath,
+		cache:         cache,
+		st:            e.st,
+		statePath:     e.statePath,
+		stableNumbers: *f.stableNumbers || e.settings.StableNumbers,
+		strict:        *f.strict,
+	}
+	return e
+}
+
+// hosts loads the menu hosts and the config files they were read from,
+// reporting why when there are none.
+func (e *env) hosts() ([]host.Host, []string, bool) {
+	hosts, files, err := e.loader.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, nil, false
+	}
 	if len(hosts) == 0 {
 		fmt.Fprintln(os.Stderr, "No menu hosts found in SSH config. Ensure hosts have a '# Menu ...' comment.")
-		os.Exit(1)
+		return nil, nil, false
+	}
+	return hosts, files, true
+}
+
+// host loads the hosts and finds the one named by a menu number or alias.
+func (e *env) host(name string) (*host.Host, bool) {
+	hosts, _, ok := e.hosts()
+	if !ok {
+		return nil, false
 	}
+	h := findHost(name, hosts)
+	if h == nil {
+		fmt.Fprintf(os.Stderr, "Host not found: %s\n", name)
+		return nil, false
+	}
+	return h, true
+}
 
-	hosts, err = host.AssignMenuNumbers(hosts)
+// editFlags are the flags of commands that edit a host's annotations.
+type editFlags struct {
+	load   *loadFlags
+	dryRun *bool
+}
+
+func addEditFlags(fs *flag.FlagSet) *editFlags {
+	return &editFlags{
+		load:   addLoadFlags(fs),
+		dryRun: fs.Bool("dry-run", false, "Print the change as a unified diff instead of writing it"),
+	}
+}
+
+// change is an edit to a file that can be previewed before it is saved.
+type change interface {
+	Changed() bool
+	Diff() string
+	Write() error
+	File() string
+}
+
+// edit finds the host named by name and applies an annotation edit to it.
+// With --dry-run the change is printed rather than written. done is
+// printed after the host alias once the change is written, and unchanged
+// when there was nothing to change.
+func (f *editFlags) edit(name string, fn func(e *env, h *host.Host) (change, error), done, unchanged string) int {
+	e := f.load.newEnv()
+	h, ok := e.host(name)
+	if !ok {
+		return exitError
+	}
+	c, err := fn(e, h)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return exitError
+	}
+	if !c.Changed() {
+		fmt.Printf("%s %s\n", h.ShortName, unchanged)
+		return exitOK
+	}
+	if *f.dryRun {
+		fmt.Print(c.Diff())
+		return exitOK
+	}
+	if err := c.Write(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
 	}
+	fmt.Printf("%s %s (%s)\n", h.ShortName, done, c.File())
+	return exitOK
+}
 
-	hosts = host.ValidateHosts(hosts)
+// selectFlags narrow the hosts a command works on.
+type selectFlags struct {
+	group *string
+	query *string
+	match *string
+	view  *string
+}
 
-	if *listGroupsPtr {
-		listGroups(hosts)
-		return
+func addSelectFlags(fs *flag.FlagSet) *selectFlags {
+	return &selectFlags{
+		group: fs.String("g", "", "Filter hosts by group"),
+		query: fs.String("q", "", "Filter hosts by query (e.g. 'env:prod role:db')"),
+		match: fs.String("m", "", "Match mode for filters: fuzzy, exact, prefix, regex or extended"),
+		view:  fs.String("view", "", "Use a saved view defined with '# View: name = query'"),
 	}
+}
 
-	if *groupPtr != "" {
-		hosts = host.HostsForGroup(hosts, *groupPtr)
-		if len(hosts) == 0 {
-			fmt.Fprintf(os.Stderr, "No hosts found in group '%s'\n", *groupPtr)
-			os.Exit(1)
-		}
+// set reports whether any flag selecting hosts was given.
+func (f *selectFlags) set() bool {
+	return *f.group != "" || *f.query != "" || *f.view != ""
+}
+
+// matchMode returns the mode from -m or the MatchMode setting.
+func (f *selectFlags) matchMode(settings config.Settings) (host.MatchMode, error) {
+	name := *f.match
+	if name == "" {
+		name = settings.MatchMode
 	}
+	if name == "" {
+		return host.ModeFuzzy, nil
+	}
+	return host.ParseMatchMode(name)
+}
 
-	if args := flag.Args(); len(args) > 0 {
-		h := findHost(args[0], hosts)
-		if h == nil {
-			fmt.Fprintln(os.Stderr, "Host not found.")
-			os.Exit(1)
+// findView returns the saved view named by --view, if any.
+func (f *selectFlags) findView(settings config.Settings) (config.View, error) {
+	if *f.view == "" {
+		return config.View{}, nil
+	}
+	view, ok := settings.FindView(*f.view)
+	if !ok {
+		return config.View{}, fmt.Errorf("no saved view named '%s'", *f.view)
+	}
+	return view, nil
+}
+
+// narrow applies -g and -q to hosts. It fails if the group is empty or
+// nothing matches the query.
+func (f *selectFlags) narrow(hosts []host.Host, mode host.MatchMode) ([]host.Host, error) {
+	if *f.group != "" {
+		hosts = host.HostsForGroup(hosts, *f.group)
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("no hosts found in group '%s'", *f.group)
 		}
-		if err := connectSSH(*h, *verbosePtr, *sshOptsPtr); err != nil {
-			fmt.Fprintf(os.Stderr, "Error executing SSH: %v\n", err)
-			os.Exit(1)
+	}
+	if *f.query != "" {
+		hosts = host.FilterHostsMode(*f.query, hosts, mode)
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("no hosts match query '%s'", *f.query)
 		}
-		return
 	}
+	return hosts, nil
+}
 
-	m := ui.New(hosts, *verbosePtr, *sshOptsPtr)
-	if err := ui.Run(m); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
-		os.Exit(1)
+// selectHosts applies every selection flag, including --view, to hosts.
+func (f *selectFlags) selectHosts(settings config.Settings, hosts []host.Host) ([]host.Host, error) {
+	mode, err := f.matchMode(settings)
+	if err != nil {
+		return nil, err
+	}
+	view, err := f.findView(settings)
+	if err != nil {
+		return nil, err
 	}
+	if hosts, err = f.narrow(hosts, mode); err != nil {
+		return nil, err
+	}
+	if view.Query != "" {
+		hosts = host.FilterHosts(view.Query, hosts)
+	}
+	return hosts, nil
+}
 
-	if m.Selected != nil {
-		if err := connectSSH(*m.Selected, *verbosePtr, *sshOptsPtr); err != nil {
-			fmt.Fprintf(os.Stderr, "Error executing SSH: %v\n", err)
-			os.Exit(1)
-		}
+// hostLoader runs the host loading pipeline: parsing, numbering,
+// validation and applying per-user state.
+type hostLoader struct {
+	configPath    string
+	cache         *config.Cache
+	st            *state.State
+	statePath     string
+	stableNumbers bool
+	strict        bool
+}
+
+// load reads the hosts in the SSH config. It also returns every config
+// file read, for watching.
+func (l *hostLoader) load() ([]host.Host, []string, error) {
+	hosts, files, err := config.ReadConfigFilesCached(l.configPath, l.cache)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading SSH config: %w", err)
+	}
+	opts := host.NumberingOptions{Strict: l.strict}
+	if l.stableNumbers {
+		opts.Persisted = l.st.Numbers()
+	}
+	hosts, err = host.AssignMenuNumbersWith(hosts, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.stableNumbers {
+		l.saveNumbers(host.AutoNumbers(hosts))
+	}
+	hosts = host.ValidateHosts(hosts)
+	applyState(hosts, l.st)
+	return hosts, files, nil
+}
+
+// saveNumbers persists auto-assigned menu numbers if they changed. Hosts
+// that are gone or now numbered explicitly are forgotten.
+func (l *hostLoader) saveNumbers(numbers map[string]int) {
+	if l.statePath == "" || !l.st.SetNumbers(numbers) {
+		return
+	}
+	if err := l.st.Save(l.statePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 }
 
@@ -89,26 +524,56 @@ func sshConfigPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Unable to determine home directory.")
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 	return filepath.Join(home, ".ssh", "config")
 }
 
-func connectSSH(h host.Host, verbose bool, sshOpts string) error {
-	var args []string
-	if verbose {
-		args = append(args, "-v")
+// applyState copies per-user state such as connection history, pins and
+// the arranged order onto hosts.
+func applyState(hosts []host.Host, st *state.State) {
+	for i := range hosts {
+		hosts[i].LastConnected = st.LastConnection(hosts[i].Key())
+		hosts[i].Pinned = st.Pinned(hosts[i].ShortName, hosts[i].PinnedInConfig())
 	}
-	if sshOpts != "" {
-		args = append(args, strings.Fields(sshOpts)...)
+	order, _ := st.Arrangement()
+	host.ApplyOrder(hosts, order)
+}
+
+// recordConnection remembers when a host was connected to, for the
+// last-connected column. Failing to save history must never block the
+// connection, so errors are only reported.
+func recordConnection(st *state.State, path string, h host.Host) {
+	if path == "" {
+		return
 	}
-	args = append(args, h.ShortName)
+	st.RecordConnection(h.Key(), time.Now())
+	if err := st.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
 
-	cmd := exec.Command("ssh", args...)
+// runAttached runs cmd on the terminal and returns the exit code to pass
+// on: the program's own status if it ran and failed, otherwise exitError
+// after reporting why it could not run.
+func runAttached(cmd *exec.Cmd) int {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	err := cmd.Run()
+	if err == nil {
+		return exitOK
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintf(os.Stderr, "Error executing %s: %v\n", filepath.Base(cmd.Path), err)
+	return exitError
+}
+
+func sshCommand(h host.Host, verbose bool, sshOpts string, remote ...string) *exec.Cmd {
+	return exec.Command("ssh", append(host.SSHArgs(h, verbose, sshOpts), remote...)...)
 }
 
 func findHost(input string, hosts []host.Host) *host.Host {
@@ -127,16 +592,3 @@ func findHost(input string, hosts []host.Host) *host.Host {
 	}
 	return nil
 }
-
-func listGroups(hosts []host.Host) {
-	groups := host.GetAllGroups(hosts)
-	if len(groups) == 0 {
-		fmt.Println("No groups found in SSH config.")
-		return
-	}
-	fmt.Println("Available groups:")
-	for _, g := range groups {
-		count := len(host.HostsForGroup(hosts, g))
-		fmt.Printf("  %s (%d hosts)\n", g, count)
-	}
-}
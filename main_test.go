@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evix1101/ssh-menu/internal/host"
+	"github.com/evix1101/ssh-menu/internal/state"
+)
+
+const testConfig = `# Menu 1: Web server
+# Group: prod
+Host web
+    HostName 10.0.0.1
+
+# Menu 2: Database
+Host db
+    HostName 10.0.0.2
+`
+
+// runCaptured runs the command line args and returns its exit code and
+// what it wrote to stdout and stderr.
+func runCaptured(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	capture := func(f **os.File) func() string {
+		tmp, err := os.CreateTemp(t.TempDir(), "out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := *f
+		*f = tmp
+		return func() string {
+			*f = saved
+			tmp.Close()
+			data, _ := os.ReadFile(tmp.Name())
+			return string(data)
+		}
+	}
+	outDone, errDone := capture(&os.Stdout), capture(&os.Stderr)
+	code = run(args)
+	return code, outDone(), errDone()
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	testEnv(t, testConfig)
+	tests := []struct {
+		args       []string
+		want       int
+		wantStderr string
+	}{
+		{[]string{"--bogus"}, exitUsage, "flag provided but not defined: -bogus"},
+		{[]string{"list", "--bogus"}, exitUsage, "flag provided but not defined: -bogus"},
+		{[]string{"list", "extra"}, exitUsage, "list takes no arguments"},
+		{[]string{"connect", "web", "extra"}, exitUsage, "too many arguments"},
+		{[]string{"pin"}, exitUsage, "expected one host"},
+		{[]string{"pin", "web", "db"}, exitUsage, "expected one host"},
+		{[]string{"connect", "nosuchhost"}, exitError, "Host not found: nosuchhost"},
+		{[]string{"pin", "nosuchhost"}, exitError, "nosuchhost"},
+		{[]string{"help", "list"}, exitOK, ""},
+		{[]string{"list", "-h"}, exitOK, ""},
+		{[]string{"groups"}, exitOK, ""},
+	}
+	for _, tt := range tests {
+		code, _, stderr := runCaptured(t, tt.args...)
+		if code != tt.want {
+			t.Errorf("%v: expected exit code %d, got %d (%s)", tt.args, tt.want, code, stderr)
+		}
+		if !strings.Contains(stderr, tt.wantStderr) {
+			t.Errorf("%v: expected %q on stderr, got %q", tt.args, tt.wantStderr, stderr)
+		}
+	}
+}
+
+func TestRun_RootIsShortForConnect(t *testing.T) {
+	testEnv(t, testConfig)
+	tests := []struct {
+		args       []string
+		want       int
+		wantStdout string
+	}{
+		{[]string{"--print", "web"}, exitOK, "web\n"},
+		{[]string{"--print", "--print-format", "hostname", "2"}, exitOK, "10.0.0.2\n"},
+		{[]string{"nosuchhost"}, exitError, ""},
+		{[]string{"web", "extra"}, exitUsage, ""},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runCaptured(t, tt.args...)
+		connectCode, connectStdout, connectStderr := runCaptured(t, append([]string{"connect"}, tt.args...)...)
+		if code != tt.want || stdout != tt.wantStdout {
+			t.Errorf("%v: expected exit code %d and %q, got %d and %q (%s)", tt.args, tt.want, tt.wantStdout, code, stdout, stderr)
+		}
+		// Usage errors name the command, so only the first line is the same.
+		firstLine := func(s string) string { return strings.SplitN(s, "\n", 2)[0] }
+		if code != connectCode || stdout != connectStdout || firstLine(stderr) != firstLine(connectStderr) {
+			t.Errorf("%v: expected the same result as connect, got %d %q %q and %d %q %q",
+				tt.args, code, stdout, stderr, connectCode, connectStdout, connectStderr)
+		}
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    []string
+		wantDry bool
+	}{
+		{[]string{"web", "new desc"}, []string{"web", "new desc"}, false},
+		{[]string{"--dry-run", "web", "new desc"}, []string{"web", "new desc"}, true},
+		{[]string{"web", "new desc", "--dry-run"}, []string{"web", "new desc"}, true},
+		{[]string{"add", "--dry-run", "web", "prod"}, []string{"add", "web", "prod"}, true},
+		{[]string{"web", "--", "-x", "--dry-run"}, []string{"web", "-x", "--dry-run"}, false},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		dryRun := fs.Bool("dry-run", false, "")
+		got, err := parseInterspersed(fs, tt.args)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if !slices.Equal(got, tt.want) || *dryRun != tt.wantDry {
+			t.Errorf("%v: expected %v and dry-run %v, got %v and %v", tt.args, tt.want, tt.wantDry, got, *dryRun)
+		}
+	}
+}
+
+func TestParseInterspersed_UnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterspersed(fs, []string{"web", "desc", "--bogus"}); err == nil {
+		t.Error("expected an error for a flag after the arguments")
+	}
+}
+
+func TestApplyState_LastConnectedPerSourceFile(t *testing.T) {
+	hosts := []host.Host{
+		{ShortName: "web", SourceFile: "a"},
+		{ShortName: "web", SourceFile: "b"},
+	}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &state.State{}
+	st.RecordConnection(hosts[1].Key(), at)
+	applyState(hosts, st)
+	if !hosts[0].LastConnected.IsZero() || !hosts[1].LastConnected.Equal(at) {
+		t.Errorf("expected only web@b to have history, got %v and %v", hosts[0].LastConnected, hosts[1].LastConnected)
+	}
+}